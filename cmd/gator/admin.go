@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+)
+
+func handlerAdmin(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 2 || (cmd.args[0] != "grant" && cmd.args[0] != "revoke" && cmd.args[0] != "passwd") {
+		return errors.New("usage: gator admin <grant|revoke|passwd> <user_name>")
+	}
+	if cmd.args[0] == "passwd" {
+		return adminPasswd(s, cmd.args[1])
+	}
+	grant, name := cmd.args[0] == "grant", cmd.args[1]
+	if !grant && name == user.Name {
+		return errors.New("you can't revoke your own admin role, ask another admin")
+	}
+
+	args := database.SetUserAdminParams{Name: name, IsAdmin: grant, UpdatedAt: time.Now()}
+	n, err := s.db.SetUserAdmin(context.Background(), args)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%s is not a registered user", name)
+	}
+	row := userRow{Name: name, Admin: grant, Current: name == s.cfg.Username}
+	if !grant {
+		return s.out.report(row, "%s is no longer an admin", name)
+	}
+	return s.out.report(row, "%s is now an admin", name)
+}
+
+// adminPasswd sets a user's password without asking for the current one,
+// the only way for users from before passwords to get one.
+func adminPasswd(s *state, name string) error {
+	target, err := s.db.GetUser(context.Background(), name)
+	if err != nil {
+		return fmt.Errorf("%s is not a registered user", name)
+	}
+	err = setPassword(s, target)
+	if err != nil {
+		return err
+	}
+	return s.out.report(passwordRow{User: name}, "password updated for %s", name)
+}
+
+// handlerDeleteUser removes a user with their follows, folders and tokens.
+// Feeds they added are handed to the admin so other followers keep them.
+func handlerDeleteUser(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return errors.New("usage: gator deluser <user_name>")
+	}
+	name := cmd.args[0]
+	if name == user.Name {
+		return errors.New("you can't delete yourself")
+	}
+	target, err := s.db.GetUser(context.Background(), name)
+	if err != nil {
+		return fmt.Errorf("%s is not a registered user", name)
+	}
+
+	// both or neither, feeds must not move away from a user who stays
+	tx, err := s.conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := s.db.WithTx(tx)
+	args := database.ReassignFeedsParams{ToUserID: user.ID, UpdatedAt: time.Now(), FromUserID: target.ID}
+	err = q.ReassignFeeds(context.Background(), args)
+	if err != nil {
+		return err
+	}
+	_, err = q.DeleteUser(context.Background(), name)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	return s.out.report(removedRow{Removed: name}, "deleted %s", name)
+}
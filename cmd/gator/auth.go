@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/auth"
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"golang.org/x/term"
+)
+
+const tokenUsage = "usage: gator token <create <name> [--save]|list|revoke <name>>"
+
+// currentUser returns the user commands run as. A token in the config wins
+// over current_user_name, and require_token makes it mandatory.
+func currentUser(s *state) (database.User, error) {
+	if s.cfg.Token != "" {
+		user, err := s.db.GetUserByToken(context.Background(), auth.HashToken(s.cfg.Token))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return database.User{}, errors.New("the token in your config is invalid or revoked, run gator login again")
+			}
+			return database.User{}, err
+		}
+		return user, nil
+	}
+	if s.cfg.RequireToken {
+		return database.User{}, errors.New("this gator requires a token, run gator login <user_name> first")
+	}
+	return s.db.GetUser(context.Background(), s.cfg.Username)
+}
+
+// stdin is shared by every prompt, a reader of its own would buffer the
+// lines piped in for the prompts after it.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readPassword prompts for a password without echoing it when stdin is a
+// terminal, and reads a plain line otherwise so passwords can be piped in.
+// Prompts go to stderr to keep stdout for output.
+func readPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		return string(b), err
+	}
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func readNewPassword() (string, error) {
+	password, err := readPassword("password: ")
+	if err != nil {
+		return "", err
+	}
+	if len(password) < 8 {
+		return "", errors.New("password must be at least 8 characters")
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		again, err := readPassword("repeat password: ")
+		if err != nil {
+			return "", err
+		}
+		if again != password {
+			return "", errors.New("passwords do not match")
+		}
+	}
+	return password, nil
+}
+
+func handlerPasswd(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 0 {
+		return errors.New("usage: gator passwd")
+	}
+	// without a password there is nothing to prove who is asking, the
+	// first one is set by an admin
+	if !user.HashedPassword.Valid {
+		return fmt.Errorf("%s has no password, ask an admin to set one with gator admin passwd %s", user.Name, user.Name)
+	}
+	current, err := readPassword("current password: ")
+	if err != nil {
+		return err
+	}
+	if auth.CheckPasswordHash(current, user.HashedPassword.String) != nil {
+		return errors.New("incorrect password")
+	}
+
+	err = setPassword(s, user)
+	if err != nil {
+		return err
+	}
+	return s.out.report(passwordRow{User: user.Name}, "password updated")
+}
+
+// passwordRow names the user whose password was set, never the password.
+type passwordRow struct {
+	User string `json:"user"`
+}
+
+// setPassword prompts for a new password for user and stores it.
+func setPassword(s *state, user database.User) error {
+	password, err := readNewPassword()
+	if err != nil {
+		return err
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	args := database.SetUserPasswordParams{ID: user.ID, HashedPassword: sql.NullString{String: hash, Valid: true}, UpdatedAt: time.Now()}
+	err = s.db.SetUserPassword(context.Background(), args)
+	if err != nil {
+		return err
+	}
+	// the Fever key is derived from the password, keep it working
+	if user.FeverApiKey.Valid {
+		return setFeverKey(s, user, password)
+	}
+	return nil
+}
+
+type feverRow struct {
+	User    string `json:"user"`
+	Enabled bool   `json:"enabled"`
+}
+
+// handlerFever turns the Fever API on or off for the user. Fever clients
+// log in with md5("name:password"), so enabling it asks for the password
+// to store that key.
+func handlerFever(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 || (cmd.args[0] != "enable" && cmd.args[0] != "disable") {
+		return errors.New("usage: gator fever <enable|disable>")
+	}
+	if cmd.args[0] == "disable" {
+		args := database.SetUserFeverKeyParams{ID: user.ID, UpdatedAt: time.Now()}
+		err := s.db.SetUserFeverKey(context.Background(), args)
+		if err != nil {
+			return err
+		}
+		return s.out.report(feverRow{User: user.Name}, "fever API disabled")
+	}
+
+	if !user.HashedPassword.Valid {
+		return errors.New("set a password with gator passwd first")
+	}
+	password, err := readPassword("password: ")
+	if err != nil {
+		return err
+	}
+	if auth.CheckPasswordHash(password, user.HashedPassword.String) != nil {
+		return errors.New("incorrect password")
+	}
+	err = setFeverKey(s, user, password)
+	if err != nil {
+		return err
+	}
+	return s.out.report(feverRow{User: user.Name, Enabled: true}, "fever API enabled, log in as %s with your password", user.Name)
+}
+
+func setFeverKey(s *state, user database.User, password string) error {
+	key := auth.FeverKey(user.Name, password)
+	args := database.SetUserFeverKeyParams{ID: user.ID, FeverApiKey: sql.NullString{String: key, Valid: true}, UpdatedAt: time.Now()}
+	return s.db.SetUserFeverKey(context.Background(), args)
+}
+
+func handlerToken(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New(tokenUsage)
+	}
+	sub, args := cmd.args[0], cmd.args[1:]
+
+	switch sub {
+	case "create":
+		fs := newFlagSet("token create")
+		save := fs.Bool("save", false, "store the token in your config so the CLI uses it")
+		rest, err := parseFlags(fs, args)
+		if err != nil || len(rest) != 1 {
+			return errors.New(tokenUsage)
+		}
+		token, err := createToken(s, user, rest[0])
+		if err != nil {
+			return err
+		}
+		if *save {
+			err = s.cfg.SetToken(token)
+			if err != nil {
+				return err
+			}
+		}
+		// the token alone on stdout, so it can be captured
+		if s.out.human() {
+			fmt.Println(token)
+		} else {
+			err = s.out.print(newTokenRow{Name: rest[0], Token: token})
+			if err != nil {
+				return err
+			}
+		}
+		s.out.note("this token will not be shown again")
+		return nil
+	case "list":
+		if len(args) != 0 {
+			return errors.New(tokenUsage)
+		}
+		return listTokens(s, user)
+	case "revoke":
+		if len(args) != 1 {
+			return errors.New(tokenUsage)
+		}
+		params := database.RevokeAPITokenParams{UserID: user.ID, Name: args[0], RevokedAt: sql.NullTime{Time: time.Now(), Valid: true}}
+		n, err := s.db.RevokeAPIToken(context.Background(), params)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("no active token named %s", args[0])
+		}
+		return s.out.report(removedRow{Removed: args[0]}, "revoked %s", args[0])
+	}
+	return errors.New(tokenUsage)
+}
+
+// loginToken creates the token saved by login and register. Its name
+// carries part of a uuid so logging in twice in a second doesn't collide.
+func loginToken(s *state, user database.User) (string, error) {
+	name := fmt.Sprintf("cli login %s %s", time.Now().Format(time.DateTime), uuid.NewString()[:8])
+	return createToken(s, user, name)
+}
+
+func createToken(s *state, user database.User, name string) (string, error) {
+	token, err := auth.MakeToken()
+	if err != nil {
+		return "", err
+	}
+	args := database.CreateAPITokenParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, Name: name, TokenHash: auth.HashToken(token)}
+	_, err = s.db.CreateAPIToken(context.Background(), args)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return "", fmt.Errorf("you already have a token named %s", name)
+		}
+		return "", err
+	}
+	return token, nil
+}
+
+type newTokenRow struct {
+	Name  string `json:"name"`
+	Token string `json:"token"`
+}
+
+type tokenRow struct {
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Revoked    bool       `json:"revoked"`
+}
+
+func listTokens(s *state, user database.User) error {
+	tokens, err := s.db.GetAPITokensForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+	rows := []tokenRow{}
+	for _, t := range tokens {
+		rows = append(rows, tokenRow{Name: t.Name, CreatedAt: t.CreatedAt, LastUsedAt: timePtr(t.LastUsedAt), Revoked: t.RevokedAt.Valid})
+	}
+	return s.out.print(rows)
+}
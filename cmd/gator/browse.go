@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/content"
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/timeline"
+	"github.com/google/uuid"
+)
+
+const browseUsage = "usage: gator browse [limit] [--feed url] [--folder name] [--since date] [--until date] [--unread] [--starred] [--category c] [--author a] [--tag t] [--after cursor]"
+
+// postFilters holds the timeline filters shared by the commands that list
+// a user's posts.
+type postFilters struct {
+	feed     string
+	folder   string
+	since    string
+	until    string
+	unread   bool
+	starred  bool
+	category string
+	author   string
+	tag      string
+	after    string
+}
+
+func (f *postFilters) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.feed, "feed", "", "only show posts from the feed with this url")
+	fs.StringVar(&f.folder, "folder", "", "only show posts from feeds in this folder")
+	fs.StringVar(&f.since, "since", "", "only show posts published on or after this date")
+	fs.StringVar(&f.until, "until", "", "only show posts published before this date")
+	fs.BoolVar(&f.unread, "unread", false, "only show posts you have not read")
+	fs.BoolVar(&f.starred, "starred", false, "only show starred posts")
+	fs.StringVar(&f.category, "category", "", "only show posts in this category")
+	fs.StringVar(&f.author, "author", "", "only show posts whose author contains this text")
+	fs.StringVar(&f.tag, "tag", "", "only show posts you tagged with this tag")
+	fs.StringVar(&f.after, "after", "", "continue from the cursor printed by a previous page")
+}
+
+func (f *postFilters) params(user database.User, limit int) (database.GetPostsForUserParams, error) {
+	args := database.GetPostsForUserParams{
+		UserID:      user.ID,
+		UnreadOnly:  f.unread,
+		StarredOnly: f.starred,
+		MaxResults:  int32(limit),
+	}
+	if f.feed != "" {
+		args.FeedUrl = sql.NullString{String: f.feed, Valid: true}
+	}
+	if f.folder != "" {
+		args.Folder = sql.NullString{String: f.folder, Valid: true}
+	}
+	if f.since != "" {
+		t, err := parseDate(f.since)
+		if err != nil {
+			return args, fmt.Errorf("could not parse --since %s: %w", f.since, err)
+		}
+		args.Since = sql.NullTime{Time: t, Valid: true}
+	}
+	if f.until != "" {
+		t, err := parseDate(f.until)
+		if err != nil {
+			return args, fmt.Errorf("could not parse --until %s: %w", f.until, err)
+		}
+		args.Until = sql.NullTime{Time: t, Valid: true}
+	}
+	if f.category != "" {
+		args.Category = sql.NullString{String: f.category, Valid: true}
+	}
+	if f.author != "" {
+		args.Author = sql.NullString{String: f.author, Valid: true}
+	}
+	if f.tag != "" {
+		args.Tag = sql.NullString{String: f.tag, Valid: true}
+	}
+	if f.after != "" {
+		c, err := timeline.ParseCursor(f.after)
+		if err != nil {
+			return args, err
+		}
+		args.AfterCreatedAt = sql.NullTime{Time: c.CreatedAt, Valid: true}
+		args.AfterID = uuid.NullUUID{UUID: c.ID, Valid: true}
+	}
+	return args, nil
+}
+
+func handlerBrowse(s *state, cmd command, user database.User) error {
+	fs := newFlagSet("browse")
+	var filters postFilters
+	filters.register(fs)
+
+	rest, err := parseFlags(fs, cmd.args)
+	if err != nil || len(rest) > 1 {
+		return errors.New(browseUsage)
+	}
+
+	limit := 2
+	if len(rest) == 1 {
+		limit, err = strconv.Atoi(rest[0])
+		if err != nil || limit <= 0 {
+			return errors.New("limit must be a positive integer, " + browseUsage)
+		}
+	}
+
+	args, err := filters.params(user, limit)
+	if err != nil {
+		return err
+	}
+	posts, err := s.db.GetPostsForUser(context.Background(), args)
+	if err != nil {
+		return err
+	}
+	ids := make([]uuid.UUID, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	tags, err := postTags(s, user, ids)
+	if err != nil {
+		return err
+	}
+	rows := []postRow{}
+	for _, p := range posts {
+		rows = append(rows, postRow{
+			ID:          p.IntID,
+			Date:        postTime(p.PublishedAt, p.CreatedAt),
+			Feed:        p.FeedName,
+			Title:       p.Title,
+			Tags:        nonNil(tags[p.ID]),
+			Url:         p.Url,
+			Author:      p.Author.String,
+			Categories:  nonNil(p.Categories),
+			UUID:        p.ID,
+			Description: content.RenderText(p.Description.String, 0),
+			html:        p.Description.String,
+		})
+	}
+	if err := s.out.print(rows); err != nil {
+		return err
+	}
+
+	if len(posts) == limit {
+		last := posts[len(posts)-1]
+		c := timeline.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		// keep the cursor out of the way of programs reading the posts
+		w := os.Stdout
+		if !s.out.human() {
+			w = os.Stderr
+		}
+		fmt.Fprintf(w, "next cursor: %s\n", c.Encode())
+	}
+	return nil
+}
+
+// postRow is a post as the commands listing posts print it. Tables show
+// the short id, date, feed, title and tags with the description wrapped
+// under each post; the other formats have all of it, with the
+// description as plain text. The date is when the post was published, or
+// stored when the feed doesn't say.
+type postRow struct {
+	ID          int64     `json:"id"`
+	Date        time.Time `json:"date"`
+	Feed        string    `json:"feed"`
+	Title       string    `json:"title"`
+	Tags        []string  `json:"tags"`
+	Url         string    `json:"url" table:"-"`
+	Author      string    `json:"author" table:"-"`
+	Categories  []string  `json:"categories" table:"-"`
+	UUID        uuid.UUID `json:"uuid" table:"-"`
+	Description string    `json:"description" table:"-"`
+
+	// html is the description as stored, rendered again for tables to
+	// wrap it to the terminal
+	html string
+}
+
+func (p postRow) detail(width int) string {
+	return content.RenderText(p.html, width)
+}
+
+// nonNil keeps empty lists as [] rather than null in JSON and YAML.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/content"
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/digest"
+	"github.com/google/uuid"
+)
+
+const digestUsage = "usage: gator digest <set <email> <daily|weekly>|off|status|send [--dry-run]>"
+
+// maxDigestPosts caps one digest, posts left over go out with the next.
+const maxDigestPosts = 200
+
+type digestRow struct {
+	Email      string     `json:"email"`
+	Frequency  string     `json:"frequency"`
+	LastSentAt *time.Time `json:"last_sent_at"`
+}
+
+type digestSentRow struct {
+	Email string `json:"email"`
+	Posts int    `json:"posts"`
+}
+
+func handlerDigest(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New(digestUsage)
+	}
+	sub, args := cmd.args[0], cmd.args[1:]
+
+	switch {
+	case sub == "set" && len(args) == 2:
+		return setDigest(s, user, args[0], args[1])
+	case sub == "off" && len(args) == 0:
+		n, err := s.db.DeleteDigest(context.Background(), user.ID)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errors.New("you have no digest set up")
+		}
+		return s.out.report(removedRow{Removed: "digest"}, "digest turned off")
+	case sub == "status" && len(args) == 0:
+		// an empty list when there's no digest, so scripts get the same
+		// shape either way
+		rows := []digestRow{}
+		d, err := s.db.GetDigest(context.Background(), user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			if s.out.human() {
+				s.out.note("no digest set up")
+				return nil
+			}
+		} else if err != nil {
+			return err
+		} else {
+			rows = append(rows, digestRow{Email: d.Email, Frequency: d.Frequency, LastSentAt: timePtr(d.LastSentAt)})
+		}
+		return s.out.print(rows)
+	case sub == "send":
+		fs := newFlagSet("digest send")
+		dryRun := fs.Bool("dry-run", false, "print the digest instead of sending it")
+		rest, err := parseFlags(fs, args)
+		if err != nil || len(rest) != 0 {
+			return errors.New(digestUsage)
+		}
+		d, err := s.db.GetDigest(context.Background(), user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("set up a digest with gator digest set first")
+		}
+		if err != nil {
+			return err
+		}
+		n, err := sendDigest(s, user.ID, user.Name, d.Email, d.Frequency, *dryRun)
+		if err != nil {
+			return err
+		}
+		if *dryRun {
+			return nil
+		}
+		return s.out.report(digestSentRow{Email: d.Email, Posts: n}, "sent %d posts to %s", n, d.Email)
+	}
+	return errors.New(digestUsage)
+}
+
+func setDigest(s *state, user database.User, email, frequency string) error {
+	if frequency != "daily" && frequency != "weekly" {
+		return errors.New(digestUsage)
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return fmt.Errorf("%s is not an email address", email)
+	}
+	args := database.SetDigestParams{UserID: user.ID, CreatedAt: time.Now(), UpdatedAt: time.Now(), Email: email, Frequency: frequency}
+	_, err := s.db.SetDigest(context.Background(), args)
+	if err != nil {
+		return err
+	}
+	return s.out.report(digestRow{Email: email, Frequency: frequency}, "%s digest of new unread posts will be sent to %s", frequency, email)
+}
+
+// sendDigests sends every digest that is due, called by gator agg. A
+// failure for one user doesn't hold up the others.
+func sendDigests(s *state) error {
+	if s.cfg.SMTP == nil {
+		return nil
+	}
+	due, err := s.db.GetDueDigests(context.Background(), time.Now())
+	if err != nil {
+		return err
+	}
+	for _, d := range due {
+		n, err := sendDigest(s, d.UserID, d.UserName, d.Email, d.Frequency, false)
+		if err != nil {
+			fmt.Printf("could not send digest to %s: %v\n", d.Email, err)
+			continue
+		}
+		if n > 0 {
+			fmt.Printf("sent digest of %d posts to %s\n", n, d.Email)
+		}
+	}
+	return nil
+}
+
+// sendDigest mails the unread posts of the feeds a user gets notifications
+// for that no earlier digest included, and records them as sent. With
+// dryRun the text version is printed and nothing is recorded. It returns
+// how many posts went out; with none, no email is sent.
+func sendDigest(s *state, userID uuid.UUID, userName, email, frequency string, dryRun bool) (int, error) {
+	if s.cfg.SMTP == nil && !dryRun {
+		return 0, errors.New("no smtp server in your config, see the README")
+	}
+	rows, err := s.db.GetDigestPosts(context.Background(), database.GetDigestPostsParams{UserID: userID, MaxResults: maxDigestPosts})
+	if err != nil {
+		return 0, err
+	}
+
+	posts := make([]digest.Post, 0, len(rows))
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, r := range rows {
+		p := digest.Post{Title: r.Title, Url: r.Url, Feed: r.FeedName, Author: r.Author.String, Excerpt: content.Excerpt(r.Description.String, 300), Date: r.CreatedAt}
+		if r.PublishedAt.Valid {
+			p.Date = r.PublishedAt.Time
+		}
+		posts = append(posts, p)
+		ids = append(ids, r.ID)
+	}
+
+	if len(posts) > 0 {
+		from := ""
+		if s.cfg.SMTP != nil {
+			from = s.cfg.SMTP.From
+		}
+		msg, err := digest.Render(from, email, userName, frequency, posts)
+		if err != nil {
+			return 0, err
+		}
+		if dryRun {
+			fmt.Printf("Subject: %s\n\n%s", msg.Subject, msg.Text)
+			return len(posts), nil
+		}
+		srv := digest.Server{Host: s.cfg.SMTP.Host, Port: s.cfg.SMTP.Port, Username: s.cfg.SMTP.Username, Password: s.cfg.SMTP.Password}
+		if err := digest.Send(srv, msg); err != nil {
+			return 0, err
+		}
+	} else if dryRun {
+		s.out.note("no new posts for a digest")
+		return 0, nil
+	}
+
+	// an empty digest still counts as sent so the schedule moves on
+	err = s.db.MarkDigestSent(context.Background(), database.MarkDigestSentParams{UserID: userID, PostIds: ids, Now: time.Now()})
+	if err != nil {
+		return 0, err
+	}
+	return len(posts), nil
+}
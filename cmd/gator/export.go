@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/opml"
+)
+
+const exportUsage = "usage: gator export <opml|posts> [flags]"
+
+func handlerExport(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New(exportUsage)
+	}
+	switch cmd.args[0] {
+	case "opml":
+		return exportOPML(s, user, cmd.args[1:])
+	case "posts":
+		return exportPosts(s, user, cmd.args[1:])
+	}
+	return errors.New(exportUsage)
+}
+
+// exportOPML writes the user's follows as OPML. Admins may export another
+// user's with --user.
+func exportOPML(s *state, caller database.User, args []string) error {
+	fs := newFlagSet("export opml")
+	userName := fs.String("user", caller.Name, "export the follows of this user, admins only for other users")
+	folder := fs.String("folder", "", "only export feeds in this folder")
+	out := fs.String("out", "", "write to this file instead of stdout")
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) != 0 {
+		return errors.New("usage: gator export opml [--user name] [--folder name] [--out file]")
+	}
+
+	user := caller
+	if *userName != caller.Name {
+		if !caller.IsAdmin {
+			return errors.New("only admins can export another user's feeds")
+		}
+		user, err = s.db.GetUser(context.Background(), *userName)
+		if err != nil {
+			return fmt.Errorf("%s is not a registered user", *userName)
+		}
+	}
+	follows, err := s.db.GetFeedFollowForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	var subs []opml.Subscription
+	for _, f := range follows {
+		if *folder != "" && f.FolderName.String != *folder && !strings.HasPrefix(f.FolderName.String, *folder+"/") {
+			continue
+		}
+		title := f.FeedName.String
+		if f.DisplayName.Valid {
+			title = f.DisplayName.String
+		}
+		subs = append(subs, opml.Subscription{
+			Title:   title,
+			XMLURL:  f.FeedUrl.String,
+			HTMLURL: f.SiteUrl.String,
+			Folder:  f.FolderName.String,
+		})
+	}
+
+	return writeOutput(*out, func(w io.Writer) error {
+		return opml.New(fmt.Sprintf("%s's feeds in gator", user.Name), subs).Write(w)
+	})
+}
+
+// writeOutput runs write against the named file, or stdout when path is
+// empty.
+func writeOutput(path string, write func(io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
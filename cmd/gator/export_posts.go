@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/content"
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+const exportPostsUsage = "usage: gator export posts --format json|csv|markdown|html [--out file] [browse filters]"
+
+// exportPageSize is how many posts are fetched per query while exporting,
+// so memory use stays flat however long the timeline is.
+const exportPageSize = 500
+
+// postWriter writes posts in one export format. begin and end wrap the
+// whole document, write is called once per post.
+type postWriter interface {
+	begin() error
+	write(p database.GetPostsForUserRow) error
+	end() error
+}
+
+func exportPosts(s *state, user database.User, args []string) error {
+	fs := newFlagSet("export posts")
+	format := fs.String("format", "", "json, csv, markdown or html")
+	out := fs.String("out", "", "write to this file instead of stdout")
+	var filters postFilters
+	filters.register(fs)
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) != 0 {
+		return errors.New(exportPostsUsage)
+	}
+
+	params, err := filters.params(user, exportPageSize)
+	if err != nil {
+		return err
+	}
+
+	// the format is checked before writeOutput creates the file, a typo
+	// must not truncate an earlier export
+	bw := bufio.NewWriter(nil)
+	pw, err := newPostWriter(*format, bw)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, func(w io.Writer) error {
+		bw.Reset(w)
+		if err := pw.begin(); err != nil {
+			return err
+		}
+
+		for {
+			posts, err := s.db.GetPostsForUser(context.Background(), params)
+			if err != nil {
+				return err
+			}
+			for _, p := range posts {
+				if err := pw.write(p); err != nil {
+					return err
+				}
+			}
+			if len(posts) < exportPageSize {
+				break
+			}
+			last := posts[len(posts)-1]
+			params.AfterCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+			params.AfterID = uuid.NullUUID{UUID: last.ID, Valid: true}
+		}
+
+		if err := pw.end(); err != nil {
+			return err
+		}
+		return bw.Flush()
+	})
+}
+
+func newPostWriter(format string, w io.Writer) (postWriter, error) {
+	switch format {
+	case "json":
+		return &jsonPostWriter{w: w}, nil
+	case "csv":
+		return &csvPostWriter{w: csv.NewWriter(w)}, nil
+	case "markdown", "md":
+		return &markdownPostWriter{w: w}, nil
+	case "html":
+		return &htmlPostWriter{w: w}, nil
+	}
+	return nil, errors.New(exportPostsUsage)
+}
+
+type exportedPost struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Url         string     `json:"url"`
+	Feed        string     `json:"feed"`
+	Author      string     `json:"author,omitempty"`
+	Categories  []string   `json:"categories"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Description string     `json:"description,omitempty"`
+}
+
+func newExportedPost(p database.GetPostsForUserRow) exportedPost {
+	e := exportedPost{
+		ID:          p.ID,
+		Title:       p.Title,
+		Url:         p.Url,
+		Feed:        p.FeedName,
+		Author:      p.Author.String,
+		Categories:  p.Categories,
+		CreatedAt:   p.CreatedAt,
+		Description: p.Description.String,
+	}
+	if e.Categories == nil {
+		e.Categories = []string{}
+	}
+	if p.PublishedAt.Valid {
+		e.PublishedAt = &p.PublishedAt.Time
+	}
+	return e
+}
+
+// postDate is the date shown for a post in the human readable formats.
+func postDate(p database.GetPostsForUserRow) string {
+	if p.PublishedAt.Valid {
+		return p.PublishedAt.Time.Format("2006-01-02 15:04")
+	}
+	return p.CreatedAt.Format("2006-01-02 15:04")
+}
+
+type jsonPostWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonPostWriter) begin() error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonPostWriter) write(p database.GetPostsForUserRow) error {
+	data, err := json.Marshal(newExportedPost(p))
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if j.count == 0 {
+		sep = "\n"
+	}
+	j.count++
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonPostWriter) end() error {
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+type csvPostWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvPostWriter) begin() error {
+	return c.w.Write([]string{"id", "title", "url", "feed", "author", "categories", "published_at", "created_at", "description"})
+}
+
+func (c *csvPostWriter) write(p database.GetPostsForUserRow) error {
+	published := ""
+	if p.PublishedAt.Valid {
+		published = p.PublishedAt.Time.Format(time.RFC3339)
+	}
+	return c.w.Write([]string{
+		p.ID.String(),
+		p.Title,
+		p.Url,
+		p.FeedName,
+		p.Author.String,
+		strings.Join(p.Categories, ";"),
+		published,
+		p.CreatedAt.Format(time.RFC3339),
+		p.Description.String,
+	})
+}
+
+func (c *csvPostWriter) end() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type markdownPostWriter struct {
+	w io.Writer
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, `*`, `\*`, `_`, `\_`)
+
+func (m *markdownPostWriter) begin() error {
+	_, err := io.WriteString(m.w, "# Posts\n")
+	return err
+}
+
+func (m *markdownPostWriter) write(p database.GetPostsForUserRow) error {
+	meta := []string{markdownEscaper.Replace(p.FeedName), postDate(p)}
+	if p.Author.Valid {
+		meta = append(meta, "by "+markdownEscaper.Replace(p.Author.String))
+	}
+	_, err := fmt.Fprintf(m.w, "\n## [%s](<%s>)\n\n_%s_\n", markdownEscaper.Replace(p.Title), p.Url, strings.Join(meta, " · "))
+	if err != nil {
+		return err
+	}
+	if p.Description.Valid {
+		_, err = fmt.Fprintf(m.w, "\n%s\n", content.RenderMarkdown(p.Description.String, 0))
+	}
+	return err
+}
+
+func (m *markdownPostWriter) end() error {
+	return nil
+}
+
+type htmlPostWriter struct {
+	w io.Writer
+}
+
+func (h *htmlPostWriter) begin() error {
+	_, err := io.WriteString(h.w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Posts</title>
+</head>
+<body>
+<h1>Posts</h1>
+`)
+	return err
+}
+
+func (h *htmlPostWriter) write(p database.GetPostsForUserRow) error {
+	meta := html.EscapeString(p.FeedName) + " &middot; " + postDate(p)
+	if p.Author.Valid {
+		meta += " &middot; by " + html.EscapeString(p.Author.String)
+	}
+	// the url comes from the feed, a javascript: link keeps just the title
+	title := html.EscapeString(p.Title)
+	if content.SafeURL(p.Url) {
+		title = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(p.Url), title)
+	}
+	_, err := fmt.Fprintf(h.w, "<article>\n<h2>%s</h2>\n<p><small>%s</small></p>\n<div>%s</div>\n</article>\n",
+		title, meta, content.Sanitize(p.Description.String))
+	return err
+}
+
+func (h *htmlPostWriter) end() error {
+	_, err := io.WriteString(h.w, "</body>\n</html>\n")
+	return err
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"io"
+)
+
+// newFlagSet returns a flag set for a command's options. Parse errors are
+// returned to the handler rather than printed, so handlers can report their
+// own usage line.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseFlags parses args against fs and returns the positional arguments.
+// Unlike fs.Parse it keeps going after the first positional argument, so
+// flags may come before or after them.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const folderUsage = "usage: gator folder <list|create <name>|rename <name> <new name>|delete <name>|move <url> <name|none>>"
+
+func handlerFolder(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New(folderUsage)
+	}
+	sub, args := cmd.args[0], cmd.args[1:]
+
+	switch {
+	case sub == "list" && len(args) == 0:
+		return listFolders(s, user)
+	case sub == "create" && len(args) == 1:
+		return createFolder(s, user, args[0])
+	case sub == "rename" && len(args) == 2:
+		return renameFolder(s, user, args[0], args[1])
+	case sub == "delete" && len(args) == 1:
+		return deleteFolder(s, user, args[0])
+	case sub == "move" && len(args) == 2:
+		return moveFollow(s, user, args[0], args[1])
+	}
+	return errors.New(folderUsage)
+}
+
+type folderRow struct {
+	Name  string `json:"name"`
+	Feeds int64  `json:"feeds"`
+}
+
+func listFolders(s *state, user database.User) error {
+	folders, err := s.db.GetFoldersForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+	rows := []folderRow{}
+	for _, f := range folders {
+		rows = append(rows, folderRow{Name: f.Name, Feeds: f.FollowCount})
+	}
+	return s.out.print(rows)
+}
+
+func createFolder(s *state, user database.User, name string) error {
+	args := database.CreateFolderParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, Name: name}
+	_, err := s.db.CreateFolder(context.Background(), args)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("folder %s already exists", name)
+		}
+		return err
+	}
+	return s.out.report(folderRow{Name: name}, "created folder %s", name)
+}
+
+type folderRenameRow struct {
+	Name    string `json:"name"`
+	NewName string `json:"new_name"`
+}
+
+func renameFolder(s *state, user database.User, name, newName string) error {
+	args := database.RenameFolderParams{NewName: newName, UpdatedAt: time.Now(), UserID: user.ID, Name: name}
+	n, err := s.db.RenameFolder(context.Background(), args)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("folder %s already exists", newName)
+		}
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("no folder named %s", name)
+	}
+	return s.out.report(folderRenameRow{Name: name, NewName: newName}, "renamed folder %s to %s", name, newName)
+}
+
+func deleteFolder(s *state, user database.User, name string) error {
+	args := database.DeleteFolderParams{UserID: user.ID, Name: name}
+	n, err := s.db.DeleteFolder(context.Background(), args)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("no folder named %s", name)
+	}
+	return s.out.report(removedRow{Removed: name}, "deleted folder %s, its feeds are still followed", name)
+}
+
+// followFolderRow is a followed feed and its folder, empty for none.
+type followFolderRow struct {
+	Feed   string `json:"feed"`
+	Url    string `json:"url"`
+	Folder string `json:"folder"`
+}
+
+// moveFollow puts the user's follow of the feed at url into the named
+// folder. The name "none" takes it out of any folder.
+func moveFollow(s *state, user database.User, url, name string) error {
+	feed, err := s.db.GetFeedByUrl(context.Background(), url)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no feed with url %s", url)
+		}
+		return err
+	}
+
+	var folderID uuid.NullUUID
+	if name != "none" {
+		folder, err := s.db.GetFolderByName(context.Background(), database.GetFolderByNameParams{UserID: user.ID, Name: name})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("no folder named %s", name)
+			}
+			return err
+		}
+		folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	args := database.SetFeedFollowFolderParams{UserID: user.ID, FeedID: feed.ID, FolderID: folderID, UpdatedAt: time.Now()}
+	n, err := s.db.SetFeedFollowFolder(context.Background(), args)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("you are not following %s", url)
+	}
+	if !folderID.Valid {
+		return s.out.report(followFolderRow{Feed: feed.Name, Url: feed.Url}, "removed %s from its folder", feed.Name)
+	}
+	return s.out.report(followFolderRow{Feed: feed.Name, Url: feed.Url, Folder: name}, "moved %s to %s", feed.Name, name)
+}
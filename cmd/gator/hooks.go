@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/hooks"
+)
+
+// defaultHookConcurrency is how many hook commands run at once when the
+// config doesn't say.
+const defaultHookConcurrency = 4
+
+// startHooks sets gator agg up to run the hooks in the config. Hooks fire
+// for the feeds the current user follows, so one has to be logged in.
+func startHooks(s *state) error {
+	if len(s.cfg.Hooks) == 0 {
+		return nil
+	}
+	for _, h := range s.cfg.Hooks {
+		if h.Command == "" {
+			return fmt.Errorf("hook %s has no command", h.Name)
+		}
+	}
+	user, err := currentUser(s)
+	if err != nil {
+		return fmt.Errorf("hooks run for the current user: %w", err)
+	}
+	concurrency := s.cfg.HookConcurrency
+	if concurrency == 0 {
+		concurrency = defaultHookConcurrency
+	}
+	s.hooks = hooks.NewRunner(concurrency)
+	s.hookUser = user
+	fmt.Printf("running %d hooks for %s\n", len(s.cfg.Hooks), user.Name)
+	return nil
+}
+
+// hookFollow returns the hook user's follow of a feed, nil when they don't
+// follow it or muted its notifications and no hook should run.
+func hookFollow(s *state, feed database.Feed) (*database.GetFeedFollowForUserRow, error) {
+	follows, err := s.db.GetFeedFollowForUser(context.Background(), s.hookUser.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range follows {
+		if f.FeedID == feed.ID && f.Notify {
+			return &f, nil
+		}
+	}
+	return nil, nil
+}
+
+// runHooks starts the configured hooks matching a post scrapeFeeds stored.
+func runHooks(s *state, feed database.Feed, follow *database.GetFeedFollowForUserRow, post database.Post) {
+	p := hooks.Post{
+		ID:          post.ID,
+		Title:       post.Title,
+		Url:         post.Url,
+		Feed:        feed.Name,
+		FeedUrl:     feed.Url,
+		Folder:      follow.FolderName.String,
+		Author:      post.Author.String,
+		Categories:  post.Categories,
+		Description: post.Description.String,
+		Content:     post.Content.String,
+		CreatedAt:   post.CreatedAt,
+	}
+	if follow.DisplayName.Valid {
+		p.Feed = follow.DisplayName.String
+	}
+	if p.Categories == nil {
+		p.Categories = []string{}
+	}
+	if post.PublishedAt.Valid {
+		p.PublishedAt = &post.PublishedAt.Time
+	}
+
+	for _, h := range s.cfg.Hooks {
+		if h.Feed != "" && h.Feed != feed.Url {
+			continue
+		}
+		if h.Folder != "" && h.Folder != follow.FolderName.String {
+			continue
+		}
+		p.Hook = h.Name
+		s.hooks.Run(h.Command, time.Duration(h.TimeoutSeconds)*time.Second, p)
+	}
+}
@@ -4,14 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"strconv"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/brinwiththevlin/aggregator/internal/auth"
 	"github.com/brinwiththevlin/aggregator/internal/config"
+	"github.com/brinwiththevlin/aggregator/internal/content"
 	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/events"
+	"github.com/brinwiththevlin/aggregator/internal/hooks"
 	"github.com/brinwiththevlin/aggregator/internal/rss"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -19,8 +25,16 @@ import (
 )
 
 type state struct {
-	db  *database.Queries
-	cfg *config.Config
+	db *database.Queries
+	// conn is the connection behind db, for transactions
+	conn *sql.DB
+	cfg  *config.Config
+	out  *output
+
+	// hooks runs the config's hooks for hookUser during gator agg, it is
+	// nil otherwise.
+	hooks    *hooks.Runner
+	hookUser database.User
 }
 
 type command struct {
@@ -41,17 +55,24 @@ func (c *commands) register(name string, f func(*state, command) error, d string
 	c.handler[name] = handler{handler: f, description: d}
 }
 
-func (c *commands) describe() error {
+type commandRow struct {
+	Name  string `json:"name"`
+	Usage string `json:"usage"`
+}
+
+func (c *commands) describe(s *state) error {
+	var rows []commandRow
 	for cmd, h := range c.handler {
-		fmt.Printf("* name: %s, usage: %s\n", cmd, h.description)
+		rows = append(rows, commandRow{Name: cmd, Usage: h.description})
 	}
-	return nil
+	slices.SortFunc(rows, func(a, b commandRow) int { return strings.Compare(a.Name, b.Name) })
+	return s.out.print(rows)
 }
 
 func (c *commands) run(s *state, cmd command) error {
 	name := cmd.name
 	if name == "help" {
-		return c.describe()
+		return c.describe(s)
 	}
 	return c.handler[name].handler(s, cmd)
 
@@ -59,13 +80,17 @@ func (c *commands) run(s *state, cmd command) error {
 
 func main() {
 
-	args := os.Args
-	if len(args) < 2 {
+	format, args, err := outputOption(os.Args[1:])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if len(args) < 1 {
 		fmt.Println("Error: Not enough arguments provided")
 		os.Exit(1)
 	}
 
-	s := state{}
+	s := state{out: &output{format: format, w: os.Stdout}}
 	cfg, err := config.Read()
 	if err != nil {
 		log.Fatal(err)
@@ -79,23 +104,52 @@ func main() {
 	}
 	dbQueries := database.New(db)
 	s.db = dbQueries
+	s.conn = db
 
 	handlers := make(map[string]handler)
 	cmds := commands{handler: handlers}
 	cmds.register("login", handlerLogin, "gator login <user_name>")
 	cmds.register("register", handlerRegister, "gator register <user_name>")
-	cmds.register("reset", handlerReset, "gator reset")
+	cmds.register("passwd", middlewareLoggedIn(handlerPasswd), "gator passwd")
+	cmds.register("token", middlewareLoggedIn(handlerToken), "gator token <create <name> [--save]|list|revoke <name>>")
+	cmds.register("webhook", middlewareLoggedIn(handlerWebhook), "gator webhook <add <name> <url> [--feed url] [--folder name] [--keyword word]|list|test <name>|log <name>|remove <name>>")
+	cmds.register("digest", middlewareLoggedIn(handlerDigest), "gator digest <set <email> <daily|weekly>|off|status|send [--dry-run]>")
+	cmds.register("rule", middlewareLoggedIn(handlerRule), "gator rule <add <name> <pattern> --action hide|read|star|tag [--tag name] [--field any|title|description|author|category] [--regex]|list|remove <name>>")
+	cmds.register("rules", middlewareLoggedIn(handlerRules), "gator rules apply [--rule name]")
+	cmds.register("fever", middlewareLoggedIn(handlerFever), "gator fever <enable|disable>")
+	cmds.register("reset", middlewareAdmin(handlerReset), "gator reset")
 	cmds.register("users", handlerUsers, "gator users")
-	cmds.register("agg", handlerAgg, "gator agg <duration>")
+	cmds.register("deluser", middlewareAdmin(handlerDeleteUser), "gator deluser <user_name>")
+	cmds.register("admin", middlewareAdmin(handlerAdmin), "gator admin <grant|revoke|passwd> <user_name>")
+	cmds.register("agg", handlerAgg, "gator agg <duration> [prune duration]")
 	cmds.register("addfeed", middlewareLoggedIn(handlerAddFeed), "gator addfeed <feed> <url>")
+	cmds.register("delfeed", middlewareLoggedIn(handlerDeleteFeed), "gator delfeed <url>")
 	cmds.register("feeds", handlerFeeds, "gator feeds")
-	cmds.register("follow", middlewareLoggedIn(handlerFollow), "gator follow <url>")
-	cmds.register("following", middlewareLoggedIn(handlerFollowing), "gator following")
+	cmds.register("follow", middlewareLoggedIn(handlerFollow), "gator follow <url> [--name name] [--notify=false] [--hide]")
+	cmds.register("following", middlewareLoggedIn(handlerFollowing), "gator following [--folder name]")
+	cmds.register("import", middlewareLoggedIn(handlerImport), "gator import opml <file>")
+	cmds.register("export", middlewareLoggedIn(handlerExport), "gator export <opml [--user name] [--folder name]|posts --format json|csv|markdown|html [browse filters]> [--out file]")
+	cmds.register("serve", handlerServe, "gator serve [--addr host:port]")
+	cmds.register("publish", middlewareLoggedIn(handlerPublish), "gator publish <outfile> [--folder name] [--format atom|rss] [--limit n]")
+	cmds.register("folder", middlewareLoggedIn(handlerFolder), "gator folder <list|create|rename|delete|move> [args]")
 	cmds.register("unfollow", middlewareLoggedIn(handlerUnfollow), "gator unfollow <url>")
-	cmds.register("browse", middlewareLoggedIn(handlerBrowse), "gator browse <limit>")
-
-	commandName := args[1]
-	commandArgs := args[2:]
+	cmds.register("browse", middlewareLoggedIn(handlerBrowse), "gator browse [limit] [--feed url] [--folder name] [--since date] [--until date] [--unread] [--starred] [--category c] [--author a] [--tag t] [--after cursor]")
+	cmds.register("tui", middlewareLoggedIn(handlerTui), "gator tui [--unread]")
+	cmds.register("star", middlewareLoggedIn(handlerStar), "gator star <post-id>")
+	cmds.register("unstar", middlewareLoggedIn(handlerUnstar), "gator unstar <post-id>")
+	cmds.register("starred", middlewareLoggedIn(handlerStarred), "gator starred")
+	cmds.register("tag", middlewareLoggedIn(handlerTag), "gator tag <post-id> <tag>...")
+	cmds.register("untag", middlewareLoggedIn(handlerUntag), "gator untag <post-id> <tag>...")
+	cmds.register("tags", middlewareLoggedIn(handlerTags), "gator tags")
+	cmds.register("read", middlewareLoggedIn(handlerRead), "gator read <post-id>")
+	cmds.register("open", middlewareLoggedIn(handlerOpen), "gator open <post-id>")
+	cmds.register("link", middlewareLoggedIn(handlerLink), "gator link <post-id>")
+	cmds.register("prune", middlewareAdmin(handlerPrune), "gator prune")
+	cmds.register("search", middlewareLoggedIn(handlerSearch), "gator search <query> [--feed url] [--since date] [--limit n]")
+	cmds.register("retention", middlewareLoggedIn(handlerRetention), "gator retention <url> [<max age days|default> <max posts|default>]")
+
+	commandName := args[0]
+	commandArgs := args[1:]
 
 	cmd := command{name: commandName, args: commandArgs}
 	err = cmds.run(&s, cmd)
@@ -113,19 +167,43 @@ func handlerLogin(s *state, cmd command) error {
 	}
 	name := cmd.args[0]
 
-	_, err := s.db.GetUser(context.Background(), name)
+	user, err := s.db.GetUser(context.Background(), name)
 	if err != nil {
 		fmt.Printf("%s is not a registered user\n", name)
 		return err
 	}
 
+	if !user.HashedPassword.Valid {
+		return fmt.Errorf("%s has no password, ask an admin to set one with gator admin passwd %s", name, name)
+	}
+	password, err := readPassword("password: ")
+	if err != nil {
+		return err
+	}
+	if auth.CheckPasswordHash(password, user.HashedPassword.String) != nil {
+		return errors.New("incorrect password")
+	}
+
 	err = s.cfg.SetUser(name)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("username set to %s\n", cmd.args[0])
-	return nil
+	// a token saved for someone else would otherwise keep winning over the
+	// new user name
+	token := ""
+	if s.cfg.RequireToken {
+		token, err = loginToken(s, user)
+		if err != nil {
+			return err
+		}
+	}
+	err = s.cfg.SetToken(token)
+	if err != nil {
+		return err
+	}
+
+	return s.out.report(userRow{Name: user.Name, Admin: user.IsAdmin, Current: true}, "username set to %s", cmd.args[0])
 }
 
 func handlerRegister(s *state, cmd command) error {
@@ -140,27 +218,51 @@ func handlerRegister(s *state, cmd command) error {
 		return errors.New("user already exists")
 	}
 
-	arg := database.CreateUserParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: name}
-	user, err := s.db.CreateUser(context.Background(), arg)
+	password, err := readNewPassword()
+	if err != nil {
+		return err
+	}
+	hash, err := auth.HashPassword(password)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("User created: %+v\n", user)
 
+	arg := database.CreateUserParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: name, HashedPassword: sql.NullString{String: hash, Valid: true}}
+	user, err := s.db.CreateUser(context.Background(), arg)
+	if err != nil {
+		return err
+	}
 	err = s.cfg.SetUser(name)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	token := ""
+	if s.cfg.RequireToken {
+		token, err = loginToken(s, user)
+		if err != nil {
+			return err
+		}
+	}
+	err = s.cfg.SetToken(token)
+	if err != nil {
+		return err
+	}
+	return s.out.report(userRow{Name: user.Name, Admin: user.IsAdmin, Current: true}, "User created: %s (%s)", user.Name, user.ID)
 }
 
-func handlerReset(s *state, cmd command) error {
+func handlerReset(s *state, cmd command, user database.User) error {
 	err := s.db.Reset(context.Background())
 	if err != nil {
 		return err
 	}
-	fmt.Println("All users have been removed")
-	return nil
+	return s.out.report(removedRow{Removed: "all users"}, "All users have been removed")
+}
+
+type userRow struct {
+	Name    string `json:"name"`
+	Admin   bool   `json:"admin"`
+	Current bool   `json:"current"`
 }
 
 func handlerUsers(s *state, cmd command) error {
@@ -168,19 +270,16 @@ func handlerUsers(s *state, cmd command) error {
 	if err != nil {
 		return err
 	}
+	rows := []userRow{}
 	for _, u := range users {
-		str := "* " + u.Name
-		if u.Name == s.cfg.Username {
-			str += " (current)"
-		}
-		fmt.Println(str)
+		rows = append(rows, userRow{Name: u.Name, Admin: u.IsAdmin, Current: u.Name == s.cfg.Username})
 	}
-	return nil
+	return s.out.print(rows)
 }
 
 func handlerAgg(s *state, cmd command) error {
-	if len(cmd.args) != 1 {
-		return errors.New("usage: gator agg <time_between_reqs>")
+	if len(cmd.args) != 1 && len(cmd.args) != 2 {
+		return errors.New("usage: gator agg <time_between_reqs> [time_between_prunes]")
 	}
 
 	delta, err := time.ParseDuration(cmd.args[0])
@@ -188,11 +287,38 @@ func handlerAgg(s *state, cmd command) error {
 		return err
 	}
 
+	var pruneEvery time.Duration
+	if len(cmd.args) == 2 {
+		pruneEvery, err = time.ParseDuration(cmd.args[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := startHooks(s); err != nil {
+		return err
+	}
+
 	fmt.Printf("Collecting feeds every %s", cmd.args[0])
+	go runWebhooks(s, webhookInterval)
 	ticker := time.NewTicker(delta)
 
+	var lastPrune time.Time
 	for ; ; <-ticker.C {
 		scrapeFeeds(s)
+		if err := sendDigests(s); err != nil {
+			fmt.Printf("could not send digests: %v\n", err)
+		}
+
+		if pruneEvery > 0 && time.Since(lastPrune) >= pruneEvery {
+			n, err := prunePosts(s)
+			if err != nil {
+				fmt.Printf("could not prune posts: %v\n", err)
+			} else {
+				fmt.Printf("pruned %d posts\n", n)
+			}
+			lastPrune = time.Now()
+		}
 	}
 
 }
@@ -206,13 +332,45 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {
 	if err != nil {
 		return err
 	}
-	fmt.Println(feed)
-	follow_arg := database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, FeedID: feed.ID}
+	follow_arg := database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, FeedID: feed.ID, Notify: true}
 	_, err = s.db.CreateFeedFollow(context.Background(), follow_arg)
 	if err != nil {
 		return err
 	}
-	return nil
+	return s.out.print(feedRow{Name: feed.Name, Url: feed.Url, SiteUrl: feed.SiteUrl.String, AddedBy: user.Name, LastFetchedAt: timePtr(feed.LastFetchedAt)})
+}
+
+func handlerDeleteFeed(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return errors.New("usage: gator delfeed <url>")
+	}
+	feed, err := s.db.GetFeedByUrl(context.Background(), cmd.args[0])
+	if err != nil {
+		return err
+	}
+	if !canEditFeed(user, feed) {
+		return errors.New("only the user who added a feed or an admin can delete it")
+	}
+
+	err = s.db.DeleteFeed(context.Background(), feed.ID)
+	if err != nil {
+		return err
+	}
+	return s.out.report(removedRow{Removed: feed.Url}, "deleted %s and its posts", feed.Name)
+}
+
+// canEditFeed reports whether user may change or delete a feed, which
+// affects everyone following it.
+func canEditFeed(user database.User, feed database.Feed) bool {
+	return user.IsAdmin || feed.UserID == user.ID
+}
+
+type feedRow struct {
+	Name          string     `json:"name"`
+	Url           string     `json:"url"`
+	SiteUrl       string     `json:"site_url" table:"-"`
+	AddedBy       string     `json:"added_by"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
 }
 
 func handlerFeeds(s *state, cmd command) error {
@@ -220,41 +378,120 @@ func handlerFeeds(s *state, cmd command) error {
 	if err != nil {
 		return err
 	}
+	rows := []feedRow{}
 	for _, f := range feeds {
-		fmt.Println(f)
+		rows = append(rows, feedRow{Name: f.Name, Url: f.Url, SiteUrl: f.SiteUrl.String, AddedBy: f.UserName, LastFetchedAt: timePtr(f.LastFetchedAt)})
 	}
-	return nil
+	return s.out.print(rows)
 }
 
 func handlerFollow(s *state, cmd command, user database.User) error {
-	if len(cmd.args) != 1 {
-		return errors.New("usage: gator follow <url>")
-	}
-	url := cmd.args[0]
+	fs := newFlagSet("follow")
+	name := fs.String("name", "", "show the feed under this name instead of its own")
+	notify := fs.Bool("notify", true, "send notifications for new posts in this feed")
+	hide := fs.Bool("hide", false, "keep the feed's posts out of browse unless asked for with --feed")
+	rest, err := parseFlags(fs, cmd.args)
+	if err != nil || len(rest) != 1 {
+		return errors.New("usage: gator follow <url> [--name name] [--notify=false] [--hide]")
+	}
+	url := rest[0]
 	feed, err := s.db.GetFeedByUrl(context.Background(), url)
 	if err != nil {
 		return err
 	}
-	params := database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), FeedID: feed.ID, UserID: user.ID}
-	follow, err := s.db.CreateFeedFollow(context.Background(), params)
+
+	var displayName sql.NullString
+	if *name != "" {
+		displayName = sql.NullString{String: *name, Valid: true}
+	}
+
+	existing, err := s.db.GetFeedFollow(context.Background(), database.GetFeedFollowParams{UserID: user.ID, FeedID: feed.ID})
+	if err == nil {
+		// already following, so only change the settings given on the
+		// command line and keep the rest.
+		settings := database.UpdateFeedFollowSettingsParams{
+			UserID:      user.ID,
+			FeedID:      feed.ID,
+			DisplayName: existing.DisplayName,
+			Notify:      existing.Notify,
+			Hidden:      existing.Hidden,
+			UpdatedAt:   time.Now(),
+		}
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "name":
+				settings.DisplayName = displayName
+			case "notify":
+				settings.Notify = *notify
+			case "hide":
+				settings.Hidden = *hide
+			}
+		})
+		err = s.db.UpdateFeedFollowSettings(context.Background(), settings)
+		if err != nil {
+			return err
+		}
+		return printFollow(s, user, feed.ID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	params := database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), FeedID: feed.ID, UserID: user.ID, DisplayName: displayName, Notify: *notify, Hidden: *hide}
+	_, err = s.db.CreateFeedFollow(context.Background(), params)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("feed name: %s\n", follow.FeedName)
-	fmt.Printf("user: %s\n", follow.UserName)
-	return nil
+	return printFollow(s, user, feed.ID)
+}
+
+// printFollow prints the user's follow of a feed as it now stands.
+func printFollow(s *state, user database.User, feedID uuid.UUID) error {
+	follows, err := s.db.GetFeedFollowForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+	for _, f := range follows {
+		if f.FeedID == feedID {
+			return s.out.print(newFollowRow(f))
+		}
+	}
+	return errors.New("follow not found")
+}
+
+type followRow struct {
+	Folder      string `json:"folder"`
+	Feed        string `json:"feed"`
+	DisplayName string `json:"display_name"`
+	Url         string `json:"url"`
+	Notify      bool   `json:"notify"`
+	Hidden      bool   `json:"hidden"`
+}
+
+func newFollowRow(f database.GetFeedFollowForUserRow) followRow {
+	return followRow{Folder: f.FolderName.String, Feed: f.FeedName.String, DisplayName: f.DisplayName.String, Url: f.FeedUrl.String, Notify: f.Notify, Hidden: f.Hidden}
 }
 
 func handlerFollowing(s *state, cmd command, user database.User) error {
+	fs := newFlagSet("following")
+	folder := fs.String("folder", "", "only list feeds in this folder")
+	rest, err := parseFlags(fs, cmd.args)
+	if err != nil || len(rest) != 0 {
+		return errors.New("usage: gator following [--folder name]")
+	}
 
 	follows, err := s.db.GetFeedFollowForUser(context.Background(), user.ID)
 	if err != nil {
 		return err
 	}
+	rows := []followRow{}
 	for _, f := range follows {
-		fmt.Println(f.FeedName)
+		if *folder != "" && f.FolderName.String != *folder {
+			continue
+		}
+		rows = append(rows, newFollowRow(f))
 	}
-	return nil
+	return s.out.print(rows)
 }
 
 func handlerUnfollow(s *state, cmd command, user database.User) error {
@@ -275,37 +512,9 @@ func handlerUnfollow(s *state, cmd command, user database.User) error {
 	return nil
 }
 
-func handlerBrowse(s *state, cmd command, user database.User) error {
-	var limit int
-	if len(cmd.args) != 1 {
-		limit = 2
-	} else {
-		var err error
-		limit, err = strconv.Atoi(cmd.args[0])
-		if err != nil {
-			return errors.New("limit must be an integer, usage: gator browse <limit>")
-		}
-	}
-
-	args := database.GetPostsForUserParams{ID: user.ID, Limit: int32(limit)}
-	posts, err := s.db.GetPostsForUser(context.Background(), args)
-	if err != nil {
-		return err
-	}
-	for _, p := range posts {
-		fmt.Println("---")
-		fmt.Println(p.Title)
-		fmt.Println(p.Url)
-		fmt.Println(p.PublishedAt.Time)
-		fmt.Println(p.Description.String)
-	}
-	return nil
-
-}
-
 func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
 	return func(s *state, cmd command) error {
-		user, err := s.db.GetUser(context.Background(), s.cfg.Username)
+		user, err := currentUser(s)
 		if err != nil {
 			return err
 		}
@@ -314,6 +523,17 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 	}
 }
 
+// middlewareAdmin is middlewareLoggedIn for commands that only admins may
+// run.
+func middlewareAdmin(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
+	return middlewareLoggedIn(func(s *state, cmd command, user database.User) error {
+		if !user.IsAdmin {
+			return fmt.Errorf("gator %s can only be run by an admin", cmd.name)
+		}
+		return handler(s, cmd, user)
+	})
+}
+
 func scrapeFeeds(s *state) error {
 	feed, err := s.db.GetNextFeedToFetch(context.Background())
 	if err != nil {
@@ -329,15 +549,58 @@ func scrapeFeeds(s *state) error {
 		return err
 	}
 
+	if rssFeed.Channel.Link != "" && rssFeed.Channel.Link != feed.SiteUrl.String {
+		site_args := database.SetFeedSiteUrlParams{ID: feed.ID, SiteUrl: sql.NullString{String: rssFeed.Channel.Link, Valid: true}}
+		err = s.db.SetFeedSiteUrl(context.Background(), site_args)
+		if err != nil {
+			return err
+		}
+	}
+
+	compiled, err := feedRules(s, feed)
+	if err != nil {
+		return err
+	}
+
+	var follow *database.GetFeedFollowForUserRow
+	if s.hooks != nil {
+		follow, err = hookFollow(s, feed)
+		if err != nil {
+			return err
+		}
+	}
+
 	for _, i := range rssFeed.Channel.Item {
+		// descriptions and bodies are stored sanitized so nothing reading
+		// them later has to trust the feed's markup
 		var desc sql.NullString
 		if i.Description != nil && *i.Description != "" {
-			desc.String = *i.Description
+			desc.String = content.Sanitize(*i.Description)
 			desc.Valid = true
 		} else {
 			desc.Valid = false
 		}
 
+		var body sql.NullString
+		if i.Content != nil && *i.Content != "" {
+			body.String = content.Sanitize(*i.Content)
+			body.Valid = true
+		}
+
+		var author sql.NullString
+		if i.Author != nil && *i.Author != "" {
+			author.String = *i.Author
+			author.Valid = true
+		} else if i.Creator != nil && *i.Creator != "" {
+			author.String = *i.Creator
+			author.Valid = true
+		}
+
+		categories := i.Categories
+		if categories == nil {
+			categories = []string{}
+		}
+
 		var pub sql.NullTime
 		if i.PubDate != nil && *i.PubDate != "" {
 			parsedTime, err := parseDate(*i.PubDate)
@@ -358,14 +621,32 @@ func scrapeFeeds(s *state) error {
 			Description: desc,
 			PublishedAt: pub,
 			FeedID:      feed.ID,
+			Content:     body,
+			Author:      author,
+			Categories:  categories,
 		}
-		_, err := s.db.CreatePost(context.Background(), args)
+		post, err := s.db.CreatePost(context.Background(), args)
 		if err != nil {
 			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
 				continue
 			}
 			return err
 		}
+		hiddenFor, err := applyRules(s, compiled, post)
+		if err != nil {
+			fmt.Printf("could not apply rules: %v\n", err)
+		}
+		err = events.Publish(context.Background(), s.db, events.NewPost(post, feed))
+		if err != nil {
+			fmt.Printf("could not publish post event: %v\n", err)
+		}
+		_, err = s.db.QueueWebhookDeliveries(context.Background(), database.QueueWebhookDeliveriesParams{Now: time.Now(), PostID: post.ID})
+		if err != nil {
+			fmt.Printf("could not queue webhooks: %v\n", err)
+		}
+		if follow != nil && !hiddenFor[s.hookUser.ID] {
+			runHooks(s, feed, follow, post)
+		}
 		fmt.Println(i.Title)
 	}
 
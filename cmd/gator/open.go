@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+// parsePostID accepts the short numeric id browse prints as well as the
+// full uuid of a post.
+func parsePostID(s *state, arg string) (uuid.UUID, error) {
+	if id, err := uuid.Parse(arg); err == nil {
+		return id, nil
+	}
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n <= 0 {
+		return uuid.Nil, fmt.Errorf("invalid post id: %s", arg)
+	}
+	id, err := s.db.GetPostIDByIntID(context.Background(), n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("no post with id %s", arg)
+	}
+	return id, err
+}
+
+// followedPost looks up a post by id in the feeds the user follows.
+func followedPost(s *state, user database.User, arg string) (database.GetPostForUserRow, error) {
+	postID, err := parsePostID(s, arg)
+	if err != nil {
+		return database.GetPostForUserRow{}, err
+	}
+	post, err := s.db.GetPostForUser(context.Background(), database.GetPostForUserParams{ID: postID, UserID: user.ID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return post, fmt.Errorf("no post with id %s in the feeds you follow", arg)
+	}
+	return post, err
+}
+
+func handlerOpen(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return errors.New("usage: gator open <post-id>")
+	}
+	post, err := followedPost(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	if err := openURL(post.Url, true); err != nil {
+		return fmt.Errorf("could not open %s: %w", post.Url, err)
+	}
+	args := database.MarkPostReadParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, PostID: post.ID}
+	if err := s.db.MarkPostRead(context.Background(), args); err != nil {
+		return err
+	}
+	s.out.note("opened %s", post.Url)
+	return nil
+}
+
+// handlerLink prints just the post's url, for piping into other tools.
+func handlerLink(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return errors.New("usage: gator link <post-id>")
+	}
+	post, err := followedPost(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(post.Url)
+	return nil
+}
+
+// openURL opens link in $BROWSER, or else the desktop's default browser.
+// Attached, the browser shares the terminal and is waited for, which
+// terminal browsers like w3m need, otherwise it is left in the
+// background. Links come from feeds, so only http(s) ones are opened:
+// file: or custom schemes would start local handlers, and a link
+// starting with - would be read as a flag.
+func openURL(link string, attach bool) error {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("only http and https links are opened")
+	}
+	var cmd *exec.Cmd
+	switch {
+	case os.Getenv("BROWSER") != "":
+		cmd = exec.Command(os.Getenv("BROWSER"), link)
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("open", link)
+	case runtime.GOOS == "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		cmd = exec.Command("xdg-open", link)
+	}
+	if attach {
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		return cmd.Run()
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/opml"
+	"github.com/google/uuid"
+)
+
+func handlerImport(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 2 || cmd.args[0] != "opml" {
+		return errors.New("usage: gator import opml <file>")
+	}
+
+	f, err := os.Open(cmd.args[1])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	doc, err := opml.Parse(f)
+	if err != nil {
+		return fmt.Errorf("could not parse %s: %w", cmd.args[1], err)
+	}
+
+	var added, existing, invalid int
+	rows := []importRow{}
+	for _, sub := range doc.Subscriptions() {
+		row := importRow{Title: sub.Title, Url: sub.XMLURL}
+		if !validFeedURL(sub.XMLURL) {
+			row.Result, row.Error = "invalid", "no usable feed url"
+			rows = append(rows, row)
+			invalid++
+			continue
+		}
+
+		isNew, err := importSubscription(s, user, sub)
+		switch {
+		case err != nil:
+			row.Result, row.Error = "invalid", err.Error()
+			invalid++
+		case isNew:
+			row.Result = "added"
+			added++
+		default:
+			row.Result = "existing"
+			existing++
+		}
+		rows = append(rows, row)
+	}
+
+	if err := s.out.print(rows); err != nil {
+		return err
+	}
+	s.out.note("%d added, %d existing, %d invalid", added, existing, invalid)
+	return nil
+}
+
+type importRow struct {
+	Title  string `json:"title"`
+	Url    string `json:"url"`
+	Result string `json:"result"`
+	Error  string `json:"error"`
+}
+
+// importSubscription makes sure the feed exists and that the user follows
+// it, filing new follows under the subscription's folder. It reports
+// whether the feed had to be created.
+func importSubscription(s *state, user database.User, sub opml.Subscription) (bool, error) {
+	ctx := context.Background()
+
+	isNew := false
+	feed, err := s.db.GetFeedByUrl(ctx, sub.XMLURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		name := sub.Title
+		if name == "" {
+			name = sub.XMLURL
+		}
+		args := database.CreateFeedParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: name, Url: sub.XMLURL, UserID: user.ID}
+		if sub.HTMLURL != "" {
+			args.SiteUrl = sql.NullString{String: sub.HTMLURL, Valid: true}
+		}
+		feed, err = s.db.CreateFeed(ctx, args)
+		isNew = true
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.db.GetFeedFollow(ctx, database.GetFeedFollowParams{UserID: user.ID, FeedID: feed.ID})
+	if err == nil {
+		return isNew, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	follow := database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, FeedID: feed.ID, Notify: true}
+	_, err = s.db.CreateFeedFollow(ctx, follow)
+	if err != nil {
+		return false, err
+	}
+
+	if sub.Folder != "" {
+		folder, err := getOrCreateFolder(s, user, sub.Folder)
+		if err != nil {
+			return false, err
+		}
+		args := database.SetFeedFollowFolderParams{UserID: user.ID, FeedID: feed.ID, FolderID: uuid.NullUUID{UUID: folder.ID, Valid: true}, UpdatedAt: time.Now()}
+		_, err = s.db.SetFeedFollowFolder(ctx, args)
+		if err != nil {
+			return false, err
+		}
+	}
+	return isNew, nil
+}
+
+func getOrCreateFolder(s *state, user database.User, name string) (database.Folder, error) {
+	folder, err := s.db.GetFolderByName(context.Background(), database.GetFolderByNameParams{UserID: user.ID, Name: name})
+	if !errors.Is(err, sql.ErrNoRows) {
+		return folder, err
+	}
+	args := database.CreateFolderParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, Name: name}
+	return s.db.CreateFolder(context.Background(), args)
+}
+
+func validFeedURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
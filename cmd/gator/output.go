@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/screen"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// detailIndent is how far tables indent the text printed under a row.
+const detailIndent = 4
+
+// outputFormats are the values of the global --output option, table is
+// the default.
+var outputFormats = []string{"table", "json", "yaml", "csv"}
+
+// maxCellWidth is where table cells are cut off, the other formats keep
+// values whole.
+const maxCellWidth = 60
+
+// output writes what commands list in the format chosen with --output.
+// Commands hand print a slice of structs, or a single one, whose json tags
+// name the fields. Fields tagged `table:"-"`, like post descriptions, are
+// left out of tables.
+type output struct {
+	format string
+	w      io.Writer
+}
+
+// outputOption takes the global --output option out of args. It may come
+// anywhere, -o only before the command name.
+func outputOption(args []string) (string, []string, error) {
+	format := "table"
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		if name != "--output" && name != "-output" && (name != "-o" || len(rest) > 0) {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				return "", nil, errors.New("--output needs a format")
+			}
+			i++
+			value = args[i]
+		}
+		format = value
+	}
+	if !slices.Contains(outputFormats, format) {
+		return "", nil, fmt.Errorf("unknown output format %s, use one of %s", format, strings.Join(outputFormats, ", "))
+	}
+	return format, rest, nil
+}
+
+// human reports whether output is for people rather than programs, for
+// the few notes commands add around their tables.
+func (o *output) human() bool {
+	return o.format == "table"
+}
+
+// note prints a confirmation or remark meant for people. Other formats
+// keep stdout for data, so there it goes to stderr.
+func (o *output) note(format string, args ...any) {
+	w := o.w
+	if !o.human() {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, format+"\n", args...)
+}
+
+// report prints the outcome of a command that changed something: the
+// message for tables, v in the other formats.
+func (o *output) report(v any, format string, args ...any) error {
+	if o.human() {
+		o.note(format, args...)
+		return nil
+	}
+	return o.print(v)
+}
+
+// removedRow is what commands that delete or revoke something report.
+type removedRow struct {
+	Removed string `json:"removed"`
+}
+
+// detailer is a row with text too long for a column, like a post's
+// description, which tables print wrapped to width under the row.
+type detailer interface {
+	detail(width int) string
+}
+
+func (o *output) print(v any) error {
+	switch o.format {
+	case "json":
+		enc := json.NewEncoder(o.w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(v)
+	case "yaml":
+		node, err := jsonNode(v)
+		if err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(o.w)
+		enc.SetIndent(2)
+		if err := enc.Encode(node); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+
+	columns, rows, untabled, err := cells(v)
+	if err != nil {
+		return err
+	}
+	if o.format == "csv" {
+		w := csv.NewWriter(o.w)
+		w.Write(columns)
+		w.WriteAll(rows)
+		return w.Error()
+	}
+	return o.table(columns, rows, untabled, details(v))
+}
+
+// jsonNode turns v into a YAML node through its JSON encoding, so YAML
+// has the same field names, order and values as JSON.
+func jsonNode(v any) (*yaml.Node, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	blockStyle(&doc)
+	return &doc, nil
+}
+
+// blockStyle drops the flow style and quoting that come from parsing
+// JSON, the encoder quotes what needs it.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+// cells flattens v into a header and rows of strings for tables and CSV,
+// and reports the columns tables leave out. The header comes from the
+// type, so an empty list still has one.
+func cells(v any) ([]string, [][]string, map[string]bool, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		single := reflect.MakeSlice(reflect.SliceOf(rv.Type()), 1, 1)
+		single.Index(0).Set(rv)
+		rv = single
+	}
+	var columns []string
+	untabled := map[string]bool{}
+	for _, f := range reflect.VisibleFields(rv.Type().Elem()) {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" || f.Anonymous {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		columns = append(columns, name)
+		if f.Tag.Get("table") == "-" {
+			untabled[name] = true
+		}
+	}
+
+	data, err := json.Marshal(rv.Interface())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// numbers stay as written, ids don't turn into floats
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var records []map[string]any
+	if err := dec.Decode(&records); err != nil {
+		return nil, nil, nil, err
+	}
+	rows := make([][]string, len(records))
+	for i, r := range records {
+		for _, c := range columns {
+			rows[i] = append(rows[i], cell(r[c]))
+		}
+	}
+	return columns, rows, untabled, nil
+}
+
+// details collects the text printed under each row of a table, nil when
+// the rows have none.
+func details(v any) []string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		if d, ok := v.(detailer); ok {
+			return []string{d.detail(detailWidth())}
+		}
+		return nil
+	}
+	if !rv.Type().Elem().Implements(reflect.TypeFor[detailer]()) {
+		return nil
+	}
+	out := make([]string, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface().(detailer).detail(detailWidth())
+	}
+	return out
+}
+
+// detailWidth is the width text under rows is wrapped to: the terminal's,
+// capped so long lines stay readable, or no wrapping when output isn't a
+// terminal.
+func detailWidth() int {
+	w, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || w <= 0 {
+		return 0
+	}
+	return min(w, 100) - detailIndent
+}
+
+func cell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		parts := make([]string, len(v))
+		for i, p := range v {
+			parts[i] = cell(p)
+		}
+		return strings.Join(parts, ", ")
+	case map[string]any:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+// table writes rows as aligned columns under an upper case header, leaving
+// out the columns not meant for tables. Timestamps are shortened to local
+// minutes and long values cut off. Each row's detail, if any, follows it
+// indented and set off by a blank line.
+func (o *output) table(columns []string, rows [][]string, untabled map[string]bool, details []string) error {
+	var keep []int
+	for i, c := range columns {
+		if !untabled[c] {
+			keep = append(keep, i)
+		}
+	}
+
+	lines := [][]string{{}}
+	for _, i := range keep {
+		lines[0] = append(lines[0], strings.ToUpper(strings.ReplaceAll(columns[i], "_", " ")))
+	}
+	for _, r := range rows {
+		var line []string
+		for _, i := range keep {
+			line = append(line, tableCell(r[i]))
+		}
+		lines = append(lines, line)
+	}
+
+	widths := make([]int, len(keep))
+	for _, line := range lines {
+		for i, c := range line {
+			widths[i] = max(widths[i], screen.Width(c))
+		}
+	}
+	for n, line := range lines {
+		var b strings.Builder
+		for i, c := range line {
+			b.WriteString(c)
+			if i < len(line)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-screen.Width(c)+2))
+			}
+		}
+		text := strings.TrimRight(b.String(), " ") + "\n"
+		// the header is line 0
+		if n > 0 && n-1 < len(details) && details[n-1] != "" {
+			text += indent(details[n-1], detailIndent) + "\n\n"
+		}
+		if _, err := io.WriteString(o.w, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func indent(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = strings.Repeat(" ", n) + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func tableCell(s string) string {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.Local().Format("2006-01-02 15:04")
+	}
+	s = strings.Join(strings.Fields(s), " ")
+	if screen.Width(s) <= maxCellWidth {
+		return s
+	}
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		if w+screen.RuneWidth(r) > maxCellWidth-1 {
+			break
+		}
+		b.WriteRune(r)
+		w += screen.RuneWidth(r)
+	}
+	return b.String() + "…"
+}
+
+// timePtr is a nullable time as output structs hold it, nil when unset.
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputOption(t *testing.T) {
+	tests := []struct {
+		args       []string
+		wantFormat string
+		wantRest   []string
+		wantErr    bool
+	}{
+		{[]string{"feeds"}, "table", []string{"feeds"}, false},
+		{[]string{"--output", "json", "feeds"}, "json", []string{"feeds"}, false},
+		{[]string{"--output=yaml", "feeds"}, "yaml", []string{"feeds"}, false},
+		{[]string{"-output", "csv", "feeds"}, "csv", []string{"feeds"}, false},
+		{[]string{"-o", "json", "feeds"}, "json", []string{"feeds"}, false},
+		{[]string{"-o=json", "feeds"}, "json", []string{"feeds"}, false},
+		{[]string{"browse", "5", "--output", "json"}, "json", []string{"browse", "5"}, false},
+		{[]string{"browse", "--feed", "u", "--output=csv", "--unread"}, "csv", []string{"browse", "--feed", "u", "--unread"}, false},
+		// after the command name -o is left to the command
+		{[]string{"export", "-o", "x"}, "table", []string{"export", "-o", "x"}, false},
+		{[]string{"--output", "json", "--output", "yaml", "feeds"}, "yaml", []string{"feeds"}, false},
+		{[]string{"--output", "xml", "feeds"}, "", nil, true},
+		{[]string{"feeds", "--output"}, "", nil, true},
+	}
+	for _, tt := range tests {
+		format, rest, err := outputOption(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("outputOption(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if format != tt.wantFormat || !slices.Equal(rest, tt.wantRest) {
+			t.Errorf("outputOption(%q) = %q, %q, want %q, %q", tt.args, format, rest, tt.wantFormat, tt.wantRest)
+		}
+	}
+}
+
+type testRow struct {
+	ID      int64      `json:"id"`
+	Name    string     `json:"name"`
+	Tags    []string   `json:"tags"`
+	Seen    *time.Time `json:"seen"`
+	Secret  string     `json:"secret" table:"-"`
+	Skipped string     `json:"-"`
+	hidden  string
+}
+
+func TestCells(t *testing.T) {
+	seen := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		name        string
+		v           any
+		wantColumns []string
+		wantRows    [][]string
+	}{
+		{
+			name:        "empty list keeps the header",
+			v:           []testRow{},
+			wantColumns: []string{"id", "name", "tags", "seen", "secret"},
+			wantRows:    [][]string{},
+		},
+		{
+			name:        "rows",
+			v:           []testRow{{ID: 9007199254740993, Name: "a", Tags: []string{"x", "y"}, Seen: &seen, Secret: "s"}, {ID: 2, Name: "b"}},
+			wantColumns: []string{"id", "name", "tags", "seen", "secret"},
+			wantRows: [][]string{
+				{"9007199254740993", "a", "x, y", "2026-03-04T05:06:07Z", "s"},
+				{"2", "b", "", "", ""},
+			},
+		},
+		{
+			name:        "single value",
+			v:           testRow{ID: 1, Name: "one"},
+			wantColumns: []string{"id", "name", "tags", "seen", "secret"},
+			wantRows:    [][]string{{"1", "one", "", "", ""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, rows, untabled, err := cells(tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(columns, tt.wantColumns) {
+				t.Errorf("columns = %q, want %q", columns, tt.wantColumns)
+			}
+			if !slices.EqualFunc(rows, tt.wantRows, slices.Equal) {
+				t.Errorf("rows = %q, want %q", rows, tt.wantRows)
+			}
+			if len(untabled) != 1 || !untabled["secret"] {
+				t.Errorf("untabled = %v, want only secret", untabled)
+			}
+		})
+	}
+}
+
+func TestTableCell(t *testing.T) {
+	ts := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"", ""},
+		{"  line\n\tbreaks  and  tabs ", "line breaks and tabs"},
+		{ts.Format(time.RFC3339), ts.Local().Format("2006-01-02 15:04")},
+		{ts.Format(time.RFC3339Nano), ts.Local().Format("2006-01-02 15:04")},
+		{"2026-03-04", "2026-03-04"},
+		{strings.Repeat("a", maxCellWidth), strings.Repeat("a", maxCellWidth)},
+		{strings.Repeat("a", maxCellWidth+1), strings.Repeat("a", maxCellWidth-1) + "…"},
+		// wide characters take two cells
+		{strings.Repeat("日", maxCellWidth), strings.Repeat("日", maxCellWidth/2-1) + "…"},
+	}
+	for _, tt := range tests {
+		if got := tableCell(tt.in); got != tt.want {
+			t.Errorf("tableCell(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPrintTable(t *testing.T) {
+	var b bytes.Buffer
+	o := &output{format: "table", w: &b}
+	rows := []testRow{{ID: 1, Name: "first", Secret: "s"}, {ID: 22, Name: "b", Tags: []string{"t"}}}
+	if err := o.print(rows); err != nil {
+		t.Fatal(err)
+	}
+	want := "ID  NAME   TAGS  SEEN\n" +
+		"1   first\n" +
+		"22  b      t\n"
+	if b.String() != want {
+		t.Errorf("table:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestPrintPostRows(t *testing.T) {
+	rows := []postRow{{
+		ID:          7,
+		Title:       "Hello",
+		Tags:        []string{},
+		Description: "Some text [1]\n\n[1] https://a.example",
+		html:        `<p>Some <a href="https://a.example">text</a></p>`,
+	}}
+
+	var b bytes.Buffer
+	o := &output{format: "table", w: &b}
+	if err := o.print(rows); err != nil {
+		t.Fatal(err)
+	}
+	// the description is rendered under the post, not left out
+	for _, want := range []string{"Hello", "    Some text [1]\n", "    [1] https://a.example\n"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("table output %q does not contain %q", b.String(), want)
+		}
+	}
+
+	b.Reset()
+	o.format = "json"
+	if err := o.print(rows); err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0]["description"] != rows[0].Description {
+		t.Errorf("json description = %v, want %q", got, rows[0].Description)
+	}
+	if _, ok := got[0]["html"]; ok {
+		t.Errorf("json has the unexported html field: %v", got[0])
+	}
+}
+
+func TestReport(t *testing.T) {
+	var b bytes.Buffer
+	o := &output{format: "table", w: &b}
+	if err := o.report(starRow{Post: "42", Starred: true}, "starred %s", "42"); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "starred 42\n" {
+		t.Errorf("table report = %q, want the message", b.String())
+	}
+
+	// scripts get the row on stdout, not the message
+	b.Reset()
+	o.format = "json"
+	if err := o.report(starRow{Post: "42", Starred: true}, "starred %s", "42"); err != nil {
+		t.Fatal(err)
+	}
+	var got starRow
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("json report %q: %v", b.String(), err)
+	}
+	if got != (starRow{Post: "42", Starred: true}) {
+		t.Errorf("json report = %+v", got)
+	}
+}
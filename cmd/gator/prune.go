@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+type pruneRow struct {
+	Pruned int64 `json:"pruned"`
+}
+
+func handlerPrune(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 0 {
+		return errors.New("usage: gator prune")
+	}
+	n, err := prunePosts(s)
+	if err != nil {
+		return err
+	}
+	return s.out.report(pruneRow{Pruned: n}, "pruned %d posts", n)
+}
+
+// retentionRow holds a feed's limits in effect, 0 being unlimited, and
+// whether each comes from the config file rather than the feed.
+type retentionRow struct {
+	Feed              string `json:"feed"`
+	Url               string `json:"url"`
+	MaxAgeDays        int    `json:"max_age_days"`
+	MaxAgeDaysDefault bool   `json:"max_age_days_default"`
+	MaxPosts          int    `json:"max_posts"`
+	MaxPostsDefault   bool   `json:"max_posts_default"`
+}
+
+func handlerRetention(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 && len(cmd.args) != 3 {
+		return errors.New("usage: gator retention <url> [<max age days|default> <max posts|default>]")
+	}
+	feed, err := s.db.GetFeedByUrl(context.Background(), cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	if len(cmd.args) == 3 {
+		if !canEditFeed(user, feed) {
+			return errors.New("only the user who added a feed or an admin can change its retention")
+		}
+		maxAge, err := parseRetentionLimit(cmd.args[1])
+		if err != nil {
+			return err
+		}
+		maxPosts, err := parseRetentionLimit(cmd.args[2])
+		if err != nil {
+			return err
+		}
+		args := database.SetFeedRetentionParams{ID: feed.ID, UpdatedAt: time.Now(), RetentionMaxAgeDays: maxAge, RetentionMaxPosts: maxPosts}
+		err = s.db.SetFeedRetention(context.Background(), args)
+		if err != nil {
+			return err
+		}
+		feed.RetentionMaxAgeDays = maxAge
+		feed.RetentionMaxPosts = maxPosts
+	}
+
+	row := retentionRow{Feed: feed.Name, Url: feed.Url}
+	row.MaxAgeDays, row.MaxAgeDaysDefault = retentionLimit(feed.RetentionMaxAgeDays, s.cfg.RetentionMaxAgeDays)
+	row.MaxPosts, row.MaxPostsDefault = retentionLimit(feed.RetentionMaxPosts, s.cfg.RetentionMaxPosts)
+	return s.out.print(row)
+}
+
+type readRow struct {
+	Post string `json:"post"`
+	Read bool   `json:"read"`
+}
+
+func handlerRead(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return errors.New("usage: gator read <post-id>")
+	}
+	post, err := followedPost(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	args := database.MarkPostReadParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, PostID: post.ID}
+	if err := s.db.MarkPostRead(context.Background(), args); err != nil {
+		return err
+	}
+	return s.out.report(readRow{Post: cmd.args[0], Read: true}, "marked %s as read", cmd.args[0])
+}
+
+// prunePosts deletes posts that fall outside their feed's retention limits,
+// falling back to the limits in the config file. Starred posts are never
+// removed, and neither are posts younger than retention_keep_unread_days
+// that some follower of the feed has not read yet.
+func prunePosts(s *state) (int64, error) {
+	args := database.PrunePostsParams{
+		MaxAgeDays:     int32(s.cfg.RetentionMaxAgeDays),
+		MaxPosts:       int32(s.cfg.RetentionMaxPosts),
+		KeepUnreadDays: int32(s.cfg.RetentionKeepUnreadDays),
+	}
+	return s.db.PrunePosts(context.Background(), args)
+}
+
+func parseRetentionLimit(arg string) (sql.NullInt32, error) {
+	if arg == "default" {
+		return sql.NullInt32{}, nil
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 {
+		return sql.NullInt32{}, fmt.Errorf("retention limit must be a non-negative integer or default, got %s", arg)
+	}
+	return sql.NullInt32{Int32: int32(n), Valid: true}, nil
+}
+
+// retentionLimit is the limit in effect for a feed and whether it is the
+// global default.
+func retentionLimit(limit sql.NullInt32, global int) (int, bool) {
+	if !limit.Valid {
+		return global, true
+	}
+	return int(limit.Int32), false
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/rss"
+)
+
+const publishUsage = "usage: gator publish <outfile> [--folder name] [--format atom|rss] [--limit n]"
+
+type publishRow struct {
+	File  string `json:"file"`
+	Posts int    `json:"posts"`
+}
+
+func handlerPublish(s *state, cmd command, user database.User) error {
+	fs := newFlagSet("publish")
+	folder := fs.String("folder", "", "only publish posts from feeds in this folder")
+	format := fs.String("format", "", "atom or rss, guessed from the file extension by default")
+	limit := fs.Int("limit", 50, "maximum number of posts in the feed")
+	rest, err := parseFlags(fs, cmd.args)
+	if err != nil || len(rest) != 1 || *limit <= 0 {
+		return errors.New(publishUsage)
+	}
+	outfile := rest[0]
+
+	if *format == "" {
+		*format = "rss"
+		if filepath.Ext(outfile) == ".atom" {
+			*format = "atom"
+		}
+	}
+	if *format != "atom" && *format != "rss" {
+		return errors.New(publishUsage)
+	}
+
+	args := database.GetPublishedPostsForUserParams{UserID: user.ID, MaxResults: int32(*limit)}
+	ch := rss.Channel{
+		Title:       fmt.Sprintf("%s's gator timeline", user.Name),
+		Description: fmt.Sprintf("Posts from the feeds %s follows", user.Name),
+		ID:          "urn:uuid:" + user.ID.String(),
+	}
+	if *folder != "" {
+		args.Folder = sql.NullString{String: *folder, Valid: true}
+		ch.Title = fmt.Sprintf("%s's %s feeds", user.Name, *folder)
+		ch.Description = fmt.Sprintf("Posts from the feeds in %s's %s folder", user.Name, *folder)
+		ch.ID += "/" + *folder
+	}
+
+	posts, err := s.db.GetPublishedPostsForUser(context.Background(), args)
+	if err != nil {
+		return err
+	}
+
+	err = writeOutput(outfile, func(w io.Writer) error {
+		if *format == "atom" {
+			return rss.WriteAtom(w, ch, posts)
+		}
+		return rss.WriteRSS(w, ch, posts)
+	})
+	if err != nil {
+		return err
+	}
+	return s.out.report(publishRow{File: outfile, Posts: len(posts)}, "published %d posts to %s", len(posts), outfile)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/rules"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const ruleUsage = "usage: gator rule <add <name> <pattern> --action hide|read|star|tag [--tag name] [--field any|title|description|author|category] [--regex]|list|remove <name>>"
+
+func handlerRule(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New(ruleUsage)
+	}
+	sub, args := cmd.args[0], cmd.args[1:]
+
+	switch {
+	case sub == "add":
+		return addRule(s, user, args)
+	case sub == "list" && len(args) == 0:
+		return listRules(s, user)
+	case sub == "remove" && len(args) == 1:
+		n, err := s.db.DeleteRule(context.Background(), database.DeleteRuleParams{UserID: user.ID, Name: args[0]})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("no rule named %s", args[0])
+		}
+		return s.out.report(removedRow{Removed: args[0]}, "removed rule %s", args[0])
+	}
+	return errors.New(ruleUsage)
+}
+
+func addRule(s *state, user database.User, args []string) error {
+	fs := newFlagSet("rule add")
+	action := fs.String("action", "", "what to do with matching posts")
+	tag := fs.String("tag", "", "tag to add with --action tag")
+	field := fs.String("field", "any", "part of the post to match")
+	regex := fs.Bool("regex", false, "treat the pattern as a regular expression")
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) != 2 {
+		return errors.New(ruleUsage)
+	}
+	name, pattern := rest[0], rest[1]
+
+	if !slices.Contains(rules.Actions, *action) {
+		return fmt.Errorf("--action must be one of %s", strings.Join(rules.Actions, ", "))
+	}
+	if (*action == "tag") != (*tag != "") {
+		return errors.New("--tag goes with --action tag and nothing else")
+	}
+	if *tag != "" {
+		if err := validTag(*tag); err != nil {
+			return err
+		}
+	}
+	if _, err := rules.Compile(*field, *regex, pattern); err != nil {
+		return err
+	}
+
+	params := database.CreateRuleParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, Name: name, Field: *field, IsRegex: *regex, Pattern: pattern, Action: *action}
+	if *tag != "" {
+		params.Tag = sql.NullString{String: *tag, Valid: true}
+	}
+	_, err = s.db.CreateRule(context.Background(), params)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("you already have a rule named %s", name)
+		}
+		return err
+	}
+	row := ruleRow{Name: name, Action: *action, Tag: *tag, Field: *field, Regex: *regex, Pattern: pattern}
+	return s.out.report(row, "added rule %s, it applies to new posts, run gator rules apply for existing ones", name)
+}
+
+type ruleRow struct {
+	Name    string `json:"name"`
+	Action  string `json:"action"`
+	Tag     string `json:"tag"`
+	Field   string `json:"field"`
+	Regex   bool   `json:"regex"`
+	Pattern string `json:"pattern"`
+}
+
+func listRules(s *state, user database.User) error {
+	list, err := s.db.GetRulesForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+	rows := []ruleRow{}
+	for _, r := range list {
+		rows = append(rows, ruleRow{Name: r.Name, Action: r.Action, Tag: r.Tag.String, Field: r.Field, Regex: r.IsRegex, Pattern: r.Pattern})
+	}
+	return s.out.print(rows)
+}
+
+type ruleMatchRow struct {
+	Rule  string `json:"rule"`
+	Posts int    `json:"posts"`
+}
+
+// handlerRules runs the user's rules over the posts already stored, as if
+// they had been there when the posts came in.
+func handlerRules(s *state, cmd command, user database.User) error {
+	fs := newFlagSet("rules apply")
+	only := fs.String("rule", "", "only apply this rule")
+	rest, err := parseFlags(fs, cmd.args)
+	if err != nil || len(rest) != 1 || rest[0] != "apply" {
+		return errors.New("usage: gator rules apply [--rule name]")
+	}
+
+	list, err := s.db.GetRulesForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+	var compiled []compiledRule
+	for _, r := range list {
+		if *only != "" && r.Name != *only {
+			continue
+		}
+		c, ok := compileRule(s, r)
+		if ok {
+			compiled = append(compiled, c)
+		}
+	}
+	if *only != "" && len(compiled) == 0 {
+		return fmt.Errorf("no rule named %s", *only)
+	}
+
+	matched := map[string]int{}
+	after := uuid.Nil
+	for {
+		posts, err := s.db.GetPostsForRules(context.Background(), database.GetPostsForRulesParams{UserID: user.ID, AfterID: after, MaxResults: 500})
+		if err != nil {
+			return err
+		}
+		for _, p := range posts {
+			post := rules.Post{Title: p.Title, Description: p.Description.String, Author: p.Author.String, Categories: p.Categories}
+			for _, c := range compiled {
+				if !c.matcher.Match(post) {
+					continue
+				}
+				if err := applyRule(s, c.rule, p.ID); err != nil {
+					return err
+				}
+				matched[c.rule.Name]++
+			}
+		}
+		if len(posts) < 500 {
+			break
+		}
+		after = posts[len(posts)-1].ID
+	}
+	rows := []ruleMatchRow{}
+	for _, c := range compiled {
+		rows = append(rows, ruleMatchRow{Rule: c.rule.Name, Posts: matched[c.rule.Name]})
+	}
+	return s.out.print(rows)
+}
+
+type compiledRule struct {
+	rule    database.Rule
+	matcher rules.Matcher
+}
+
+// compileRule compiles a stored rule, rules that no longer compile are
+// reported and skipped rather than stopping ingestion.
+func compileRule(s *state, r database.Rule) (compiledRule, bool) {
+	m, err := rules.Compile(r.Field, r.IsRegex, r.Pattern)
+	if err != nil {
+		s.out.note("skipping rule %s: %v", r.Name, err)
+		return compiledRule{}, false
+	}
+	return compiledRule{rule: r, matcher: m}, true
+}
+
+// feedRules loads the rules of every user following a feed, for
+// scrapeFeeds to apply to the feed's new posts.
+func feedRules(s *state, feed database.Feed) ([]compiledRule, error) {
+	list, err := s.db.GetRulesForFeed(context.Background(), feed.ID)
+	if err != nil {
+		return nil, err
+	}
+	var compiled []compiledRule
+	for _, r := range list {
+		if c, ok := compileRule(s, r); ok {
+			compiled = append(compiled, c)
+		}
+	}
+	return compiled, nil
+}
+
+// applyRules runs the matching rules on a new post and returns the users
+// who hid it.
+func applyRules(s *state, compiled []compiledRule, post database.Post) (map[uuid.UUID]bool, error) {
+	hidden := map[uuid.UUID]bool{}
+	p := rules.Post{Title: post.Title, Description: post.Description.String, Author: post.Author.String, Categories: post.Categories}
+	for _, c := range compiled {
+		if !c.matcher.Match(p) {
+			continue
+		}
+		if err := applyRule(s, c.rule, post.ID); err != nil {
+			return nil, err
+		}
+		if c.rule.Action == "hide" {
+			hidden[c.rule.UserID] = true
+		}
+	}
+	return hidden, nil
+}
+
+// applyRule carries out a rule's action on a post for the rule's owner.
+// Doing it twice is harmless.
+func applyRule(s *state, r database.Rule, postID uuid.UUID) error {
+	ctx := context.Background()
+	switch r.Action {
+	case "hide":
+		return s.db.HidePost(ctx, database.HidePostParams{UserID: r.UserID, PostID: postID, RuleID: uuid.NullUUID{UUID: r.ID, Valid: true}, CreatedAt: time.Now()})
+	case "read":
+		return s.db.MarkPostRead(ctx, database.MarkPostReadParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: r.UserID, PostID: postID})
+	case "star":
+		_, err := s.db.CreateSavedPost(ctx, database.CreateSavedPostParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: r.UserID, PostID: postID})
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return nil
+		}
+		return err
+	case "tag":
+		return s.db.TagPost(ctx, database.TagPostParams{Now: time.Now(), UserID: r.UserID, Tag: r.Tag.String, PostID: postID})
+	}
+	return fmt.Errorf("rule %s has unknown action %s", r.Name, r.Action)
+}
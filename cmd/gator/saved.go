@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/content"
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type starRow struct {
+	Post    string `json:"post"`
+	Starred bool   `json:"starred"`
+}
+
+func handlerStar(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return errors.New("usage: gator star <post-id>")
+	}
+	post, err := followedPost(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	args := database.CreateSavedPostParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, PostID: post.ID}
+	_, err = s.db.CreateSavedPost(context.Background(), args)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return s.out.report(starRow{Post: cmd.args[0], Starred: true}, "post is already starred")
+		}
+		return err
+	}
+	return s.out.report(starRow{Post: cmd.args[0], Starred: true}, "starred %s", cmd.args[0])
+}
+
+func handlerUnstar(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 1 {
+		return errors.New("usage: gator unstar <post-id>")
+	}
+	postID, err := parsePostID(s, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	args := database.DeleteSavedPostParams{UserID: user.ID, PostID: postID}
+	n, err := s.db.DeleteSavedPost(context.Background(), args)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("post %s is not starred", cmd.args[0])
+	}
+	return s.out.report(starRow{Post: cmd.args[0], Starred: false}, "unstarred %s", cmd.args[0])
+}
+
+type starredRow struct {
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	PublishedAt *time.Time `json:"published_at"`
+	SavedAt     time.Time  `json:"saved_at"`
+	Url         string     `json:"url" table:"-"`
+	UUID        uuid.UUID  `json:"uuid" table:"-"`
+	Description string     `json:"description" table:"-"`
+
+	html string
+}
+
+func (p starredRow) detail(width int) string {
+	return content.RenderText(p.html, width)
+}
+
+func handlerStarred(s *state, cmd command, user database.User) error {
+	posts, err := s.db.GetSavedPostsForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+	rows := []starredRow{}
+	for _, p := range posts {
+		rows = append(rows, starredRow{ID: p.IntID, Title: p.Title, PublishedAt: timePtr(p.PublishedAt), SavedAt: p.SavedAt, Url: p.Url, UUID: p.ID, Description: content.RenderText(p.Description.String, 0), html: p.Description.String})
+	}
+	return s.out.print(rows)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+const searchUsage = "usage: gator search <query> [--feed url] [--since date] [--limit n]"
+
+// searchRow is a search result, best matches first. The headline has the
+// matching words in **bold**.
+type searchRow struct {
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Feed        string     `json:"feed"`
+	PublishedAt *time.Time `json:"published_at"`
+	Headline    string     `json:"headline"`
+	Rank        float32    `json:"rank" table:"-"`
+	Url         string     `json:"url" table:"-"`
+	UUID        uuid.UUID  `json:"uuid" table:"-"`
+}
+
+func handlerSearch(s *state, cmd command, user database.User) error {
+	fs := newFlagSet("search")
+	feedURL := fs.String("feed", "", "only search posts from this feed")
+	since := fs.String("since", "", "only search posts published on or after this date")
+	limit := fs.Int("limit", 10, "maximum number of results")
+
+	terms, err := parseFlags(fs, cmd.args)
+	if err != nil || len(terms) == 0 {
+		return errors.New(searchUsage)
+	}
+	if *limit <= 0 {
+		return errors.New("limit must be a positive integer, " + searchUsage)
+	}
+
+	args := database.SearchPostsForUserParams{
+		Query:      strings.Join(terms, " "),
+		UserID:     user.ID,
+		MaxResults: int32(*limit),
+	}
+	if *feedURL != "" {
+		args.FeedUrl = sql.NullString{String: *feedURL, Valid: true}
+	}
+	if *since != "" {
+		t, err := parseDate(*since)
+		if err != nil {
+			return fmt.Errorf("could not parse --since %s: %w", *since, err)
+		}
+		args.Since = sql.NullTime{Time: t, Valid: true}
+	}
+
+	results, err := s.db.SearchPostsForUser(context.Background(), args)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 && s.out.human() {
+		s.out.note("no matching posts")
+		return nil
+	}
+	rows := []searchRow{}
+	for _, r := range results {
+		rows = append(rows, searchRow{ID: r.IntID, Title: r.Title, Feed: r.FeedName, PublishedAt: timePtr(r.PublishedAt), Headline: r.Headline, Rank: r.Rank, Url: r.Url, UUID: r.ID})
+	}
+	return s.out.print(rows)
+}
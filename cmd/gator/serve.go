@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/brinwiththevlin/aggregator/internal/events"
+	"github.com/brinwiththevlin/aggregator/internal/server"
+)
+
+func handlerServe(s *state, cmd command) error {
+	fs := newFlagSet("serve")
+	addr := fs.String("addr", ":8080", "address to listen on")
+	rest, err := parseFlags(fs, cmd.args)
+	if err != nil || len(rest) != 0 {
+		return errors.New("usage: gator serve [--addr host:port]")
+	}
+
+	hub, err := events.Listen(s.cfg.Url, nil)
+	if err != nil {
+		return fmt.Errorf("could not listen for new posts: %w", err)
+	}
+	defer hub.Close()
+
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: server.New(s.conn, hub),
+	}
+	fmt.Printf("Serving the gator API on %s\n", *addr)
+	return srv.ListenAndServe()
+}
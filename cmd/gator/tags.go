@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+type postTagsRow struct {
+	Post string   `json:"post"`
+	Tags []string `json:"tags"`
+}
+
+func handlerTag(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return errors.New("usage: gator tag <post-id> <tag>...")
+	}
+	for _, tag := range cmd.args[1:] {
+		if err := validTag(tag); err != nil {
+			return err
+		}
+	}
+	// only posts the user can browse, a tag elsewhere could never be seen
+	post, err := followedPost(s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range cmd.args[1:] {
+		args := database.TagPostParams{Now: time.Now(), UserID: user.ID, Tag: tag, PostID: post.ID}
+		err = s.db.TagPost(context.Background(), args)
+		if err != nil {
+			return err
+		}
+	}
+	row := postTagsRow{Post: cmd.args[0], Tags: cmd.args[1:]}
+	return s.out.report(row, "tagged %s with %s", cmd.args[0], strings.Join(cmd.args[1:], ", "))
+}
+
+func handlerUntag(s *state, cmd command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return errors.New("usage: gator untag <post-id> <tag>...")
+	}
+	postID, err := parsePostID(s, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range cmd.args[1:] {
+		args := database.UntagPostParams{UserID: user.ID, PostID: postID, Name: tag}
+		n, err := s.db.UntagPost(context.Background(), args)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("post %s is not tagged %s", cmd.args[0], tag)
+		}
+	}
+	return s.out.report(postTagsRow{Post: cmd.args[0], Tags: cmd.args[1:]}, "untagged %s", cmd.args[0])
+}
+
+type tagRow struct {
+	Name  string `json:"name"`
+	Posts int64  `json:"posts"`
+}
+
+func handlerTags(s *state, cmd command, user database.User) error {
+	if len(cmd.args) != 0 {
+		return errors.New("usage: gator tags")
+	}
+	tags, err := s.db.GetTagsForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+	rows := []tagRow{}
+	for _, t := range tags {
+		rows = append(rows, tagRow{Name: t.Name, Posts: t.Posts})
+	}
+	return s.out.print(rows)
+}
+
+// validTag keeps tags to single words so they read well in lists and can
+// be typed on the command line without quoting.
+func validTag(tag string) error {
+	if tag == "" || strings.ContainsFunc(tag, func(r rune) bool { return r == ' ' || r == '\t' || r == ',' }) {
+		return fmt.Errorf("invalid tag %q, tags can't be empty or contain spaces or commas", tag)
+	}
+	return nil
+}
+
+// postTags returns the user's tags on posts by post id.
+func postTags(s *state, user database.User, ids []uuid.UUID) (map[uuid.UUID][]string, error) {
+	rows, err := s.db.GetTagsForPosts(context.Background(), database.GetTagsForPostsParams{UserID: user.ID, PostIds: ids})
+	if err != nil {
+		return nil, err
+	}
+	tags := map[uuid.UUID][]string{}
+	for _, r := range rows {
+		tags[r.PostID] = append(tags[r.PostID], r.Name)
+	}
+	return tags, nil
+}
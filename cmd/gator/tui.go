@@ -0,0 +1,723 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/content"
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/events"
+	"github.com/brinwiththevlin/aggregator/internal/screen"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// tuiPageSize is how many posts the post list loads at a time, more are
+// loaded when the selection reaches the end.
+const tuiPageSize = 100
+
+const tuiHelp = "q quit  tab switch pane  enter open  n/p next/prev  r read  s star  o browser  u unread only  R reload"
+
+const (
+	paneSources = iota
+	panePosts
+	paneArticle
+)
+
+// tuiSource is an entry of the left pane, a set of posts to list.
+type tuiSource struct {
+	label   string
+	unread  int64
+	indent  bool
+	feed    string
+	folder  string
+	starred bool
+}
+
+type tuiPost struct {
+	database.GetPostsForUserRow
+	read    bool
+	starred bool
+}
+
+// tui is the state of gator tui between key presses.
+type tui struct {
+	s    *state
+	user database.User
+	scr  *screen.Screen
+
+	sources   []tuiSource
+	source    int
+	sourceTop int
+	followed  map[uuid.UUID]bool
+
+	posts      []tuiPost
+	post       int
+	postTop    int
+	more       bool
+	unreadOnly bool
+
+	// the open post, rendered to lines for articleWidth
+	opened       database.GetPostForUserRow
+	article      []string
+	articleWidth int
+	scroll       int
+
+	focus  int
+	status string
+}
+
+func handlerTui(s *state, cmd command, user database.User) error {
+	fs := newFlagSet("tui")
+	unread := fs.Bool("unread", false, "start with only unread posts listed")
+	rest, err := parseFlags(fs, cmd.args)
+	if err != nil || len(rest) != 0 {
+		return errors.New("usage: gator tui [--unread]")
+	}
+
+	t := &tui{s: s, user: user, unreadOnly: *unread, focus: panePosts}
+	if err := t.loadSources(); err != nil {
+		return err
+	}
+	if err := t.loadPosts(); err != nil {
+		return err
+	}
+
+	// the list refreshes when gator agg stores posts, if the database
+	// connection allows listening
+	// the screen is taken over, so listener errors go to the status line
+	var live <-chan events.Post
+	liveErrs := make(chan error, 1)
+	hub, err := events.Listen(s.cfg.Url, func(err error) {
+		select {
+		case liveErrs <- err:
+		default:
+		}
+	})
+	if err != nil {
+		t.status = fmt.Sprintf("not refreshing live: %v", err)
+	} else {
+		defer hub.Close()
+		sub, unsubscribe := hub.Subscribe()
+		defer unsubscribe()
+		live = sub
+	}
+
+	t.scr, err = screen.Open()
+	if err != nil {
+		return err
+	}
+	defer t.scr.Close()
+
+	keys := t.scr.Keys()
+	// there's no portable resize signal, check the size every second
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		t.draw()
+		select {
+		case key, ok := <-keys:
+			if !ok || key == "q" || key == "ctrl+c" {
+				return nil
+			}
+			t.status = ""
+			if err := t.key(key); err != nil {
+				t.status = "error: " + err.Error()
+			}
+		case p := <-live:
+			if err := t.newPosts(p, live); err != nil {
+				t.status = "error: " + err.Error()
+			}
+		case err := <-liveErrs:
+			t.status = "live refresh: " + err.Error()
+		case <-tick.C:
+			t.scr.Resize()
+		}
+	}
+}
+
+func (t *tui) key(key string) error {
+	switch key {
+	case "tab", "l", "right":
+		if t.focus == paneSources {
+			return t.selectSource()
+		}
+		if t.focus == panePosts {
+			return t.openPost()
+		}
+	case "backtab", "h", "left", "esc", "backspace":
+		if t.focus > paneSources {
+			t.focus--
+		}
+	case "enter":
+		switch t.focus {
+		case paneSources:
+			return t.selectSource()
+		case panePosts:
+			return t.openPost()
+		}
+		return t.move(1)
+	case "j", "down":
+		return t.move(1)
+	case "k", "up":
+		return t.move(-1)
+	case " ", "pgdown", "ctrl+d":
+		return t.move(t.pageHeight())
+	case "pgup", "ctrl+u":
+		return t.move(-t.pageHeight())
+	case "g", "home":
+		return t.move(-1 << 30)
+	case "G", "end":
+		return t.move(1 << 30)
+	case "n", "p":
+		if len(t.posts) == 0 {
+			return nil
+		}
+		delta := 1
+		if key == "p" {
+			delta = -1
+		}
+		if t.post+delta < 0 || t.post+delta >= len(t.posts) {
+			return nil
+		}
+		t.post += delta
+		if err := t.maybeLoadMore(); err != nil {
+			return err
+		}
+		return t.openPost()
+	case "r":
+		return t.toggleRead()
+	case "s":
+		return t.toggleStar()
+	case "o":
+		return t.openInBrowser()
+	case "u":
+		t.unreadOnly = !t.unreadOnly
+		return t.loadPosts()
+	case "R":
+		if err := t.loadSources(); err != nil {
+			return err
+		}
+		return t.loadPosts()
+	}
+	return nil
+}
+
+// move moves the selection of the focused pane, or scrolls the article.
+func (t *tui) move(delta int) error {
+	switch t.focus {
+	case paneSources:
+		t.source = clamp(t.source+delta, 0, len(t.sources)-1)
+	case panePosts:
+		t.post = clamp(t.post+delta, 0, len(t.posts)-1)
+		return t.maybeLoadMore()
+	case paneArticle:
+		t.scroll = clamp(t.scroll+delta, 0, len(t.article)-1)
+	}
+	return nil
+}
+
+func clamp(n, low, high int) int {
+	return max(low, min(n, high))
+}
+
+// loadSources builds the left pane from the user's follows: everything,
+// starred posts, feeds outside folders, then each folder with its feeds.
+func (t *tui) loadSources() error {
+	counts, err := t.s.db.GetUnreadCountsForUser(context.Background(), t.user.ID)
+	if err != nil {
+		return err
+	}
+	selected := tuiSource{}
+	if t.source < len(t.sources) {
+		selected = t.sources[t.source]
+	}
+
+	all := tuiSource{label: "All posts"}
+	t.followed = map[uuid.UUID]bool{}
+	for _, c := range counts {
+		t.followed[c.FeedID] = true
+		if !c.Hidden {
+			all.unread += c.Unread
+		}
+	}
+	sources := []tuiSource{all, {label: "Starred", starred: true}}
+	// counts are ordered by folder with the feeds outside folders first
+	folder := -1
+	for i, c := range counts {
+		if !c.FolderName.Valid {
+			sources = append(sources, tuiSource{label: c.FeedName, unread: c.Unread, feed: c.FeedUrl})
+			continue
+		}
+		if i == 0 || counts[i-1].FolderName != c.FolderName {
+			folder = len(sources)
+			sources = append(sources, tuiSource{label: c.FolderName.String + "/", folder: c.FolderName.String})
+		}
+		if !c.Hidden {
+			sources[folder].unread += c.Unread
+		}
+		sources = append(sources, tuiSource{label: c.FeedName, unread: c.Unread, indent: true, feed: c.FeedUrl})
+	}
+	t.sources = sources
+
+	// keep the same entry selected across reloads
+	t.source = 0
+	for i, src := range sources {
+		if src.feed == selected.feed && src.folder == selected.folder && src.starred == selected.starred {
+			t.source = i
+			break
+		}
+	}
+	return nil
+}
+
+func (t *tui) selectSource() error {
+	if err := t.loadPosts(); err != nil {
+		return err
+	}
+	t.focus = panePosts
+	return nil
+}
+
+// loadPosts lists the first page of the selected source's posts, keeping
+// the selected post selected if it is still there.
+func (t *tui) loadPosts() error {
+	var selected uuid.UUID
+	if t.post < len(t.posts) {
+		selected = t.posts[t.post].ID
+	}
+	posts, err := t.fetchPosts(nil)
+	if err != nil {
+		return err
+	}
+	t.posts = posts
+	t.post = 0
+	for i, p := range posts {
+		if p.ID == selected {
+			t.post = i
+			break
+		}
+	}
+	return nil
+}
+
+// maybeLoadMore loads the next page when the selection reached the end
+// of a full list.
+func (t *tui) maybeLoadMore() error {
+	if !t.more || t.post < len(t.posts)-1 {
+		return nil
+	}
+	posts, err := t.fetchPosts(&t.posts[len(t.posts)-1])
+	if err != nil {
+		return err
+	}
+	t.posts = append(t.posts, posts...)
+	return nil
+}
+
+// fetchPosts gets a page of the selected source's posts with their read
+// and starred state, after the given post or from the newest.
+func (t *tui) fetchPosts(after *tuiPost) ([]tuiPost, error) {
+	src := t.sources[t.source]
+	args := database.GetPostsForUserParams{
+		UserID:      t.user.ID,
+		UnreadOnly:  t.unreadOnly,
+		StarredOnly: src.starred,
+		MaxResults:  tuiPageSize,
+	}
+	if src.feed != "" {
+		args.FeedUrl = sql.NullString{String: src.feed, Valid: true}
+	}
+	if src.folder != "" {
+		args.Folder = sql.NullString{String: src.folder, Valid: true}
+	}
+	if after != nil {
+		args.AfterCreatedAt = sql.NullTime{Time: after.CreatedAt, Valid: true}
+		args.AfterID = uuid.NullUUID{UUID: after.ID, Valid: true}
+	}
+	rows, err := t.s.db.GetPostsForUser(context.Background(), args)
+	if err != nil {
+		return nil, err
+	}
+	t.more = len(rows) == tuiPageSize
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, r := range rows {
+		ids[i] = r.ID
+	}
+	states, err := t.s.db.GetPostStatesForUser(context.Background(), database.GetPostStatesForUserParams{UserID: t.user.ID, PostIds: ids})
+	if err != nil {
+		return nil, err
+	}
+	read := map[uuid.UUID]bool{}
+	starred := map[uuid.UUID]bool{}
+	for _, st := range states {
+		read[st.ID] = st.IsRead
+		starred[st.ID] = st.IsStarred
+	}
+	posts := make([]tuiPost, len(rows))
+	for i, r := range rows {
+		posts[i] = tuiPost{GetPostsForUserRow: r, read: read[r.ID], starred: starred[r.ID]}
+	}
+	return posts, nil
+}
+
+// openPost shows the selected post in the article pane and marks it read.
+func (t *tui) openPost() error {
+	if len(t.posts) == 0 {
+		return nil
+	}
+	p := &t.posts[t.post]
+	post, err := t.s.db.GetPostForUser(context.Background(), database.GetPostForUserParams{ID: p.ID, UserID: t.user.ID})
+	if err != nil {
+		return err
+	}
+	t.opened = post
+	t.scroll = 0
+	t.focus = paneArticle
+	t.renderArticle()
+	if !p.read {
+		return t.setRead(p, true)
+	}
+	return nil
+}
+
+func (t *tui) renderArticle() {
+	post := t.opened
+	_, _, width, _ := t.articleArea()
+	t.articleWidth = width
+
+	meta := []string{post.FeedName, postTime(post.PublishedAt, post.CreatedAt).Format("Mon, 02 Jan 2006 15:04")}
+	if post.Author.Valid {
+		meta = append(meta, "by "+post.Author.String)
+	}
+	lines := []string{post.Title, strings.Join(meta, " · "), post.Url}
+	if len(post.Categories) > 0 {
+		lines = append(lines, "categories: "+strings.Join(post.Categories, ", "))
+	}
+	lines = append(lines, "")
+
+	body := post.Content.String
+	if !post.Content.Valid {
+		body = post.Description.String
+	}
+	lines = append(lines, strings.Split(content.RenderText(body, width-2), "\n")...)
+	t.article = lines
+}
+
+func postTime(published sql.NullTime, created time.Time) time.Time {
+	if published.Valid {
+		return published.Time.Local()
+	}
+	return created.Local()
+}
+
+// current is the post actions apply to: the open article when it has
+// focus, the selected post otherwise.
+func (t *tui) current() *tuiPost {
+	for i := range t.posts {
+		if t.focus == paneArticle && t.posts[i].ID == t.opened.ID || t.focus != paneArticle && i == t.post {
+			return &t.posts[i]
+		}
+	}
+	return nil
+}
+
+func (t *tui) toggleRead() error {
+	p := t.current()
+	if p == nil {
+		return nil
+	}
+	return t.setRead(p, !p.read)
+}
+
+func (t *tui) setRead(p *tuiPost, read bool) error {
+	ctx := context.Background()
+	var err error
+	if read {
+		err = t.s.db.MarkPostRead(ctx, database.MarkPostReadParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: t.user.ID, PostID: p.ID})
+	} else {
+		err = t.s.db.MarkPostUnread(ctx, database.MarkPostUnreadParams{UserID: t.user.ID, PostID: p.ID})
+	}
+	if err != nil {
+		return err
+	}
+	p.read = read
+	return t.loadSources()
+}
+
+func (t *tui) toggleStar() error {
+	p := t.current()
+	if p == nil {
+		return nil
+	}
+	ctx := context.Background()
+	if p.starred {
+		_, err := t.s.db.DeleteSavedPost(ctx, database.DeleteSavedPostParams{UserID: t.user.ID, PostID: p.ID})
+		if err != nil {
+			return err
+		}
+		p.starred = false
+		t.status = "unstarred " + p.Title
+		return nil
+	}
+	_, err := t.s.db.CreateSavedPost(ctx, database.CreateSavedPostParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: t.user.ID, PostID: p.ID})
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	p.starred = true
+	t.status = "starred " + p.Title
+	return nil
+}
+
+func (t *tui) openInBrowser() error {
+	p := t.current()
+	if p == nil {
+		return nil
+	}
+	if err := openURL(p.Url, false); err != nil {
+		return fmt.Errorf("could not open %s: %w", p.Url, err)
+	}
+	t.status = "opened " + p.Url
+	if !p.read {
+		return t.setRead(p, true)
+	}
+	return nil
+}
+
+// newPosts handles a post event from gator agg and any others already
+// waiting, refreshing the counts and the list when they concern the user.
+func (t *tui) newPosts(first events.Post, live <-chan events.Post) error {
+	batch := []events.Post{first}
+	for more := true; more; {
+		select {
+		case p := <-live:
+			batch = append(batch, p)
+		default:
+			more = false
+		}
+	}
+
+	var mine []events.Post
+	for _, p := range batch {
+		if t.followed[p.FeedID] {
+			mine = append(mine, p)
+		}
+	}
+	if len(mine) == 0 {
+		return nil
+	}
+	if err := t.loadSources(); err != nil {
+		return err
+	}
+	// don't pull the list from under someone paging through older posts
+	if t.post < t.pageHeight() {
+		if err := t.loadPosts(); err != nil {
+			return err
+		}
+	}
+	if len(mine) == 1 {
+		t.status = fmt.Sprintf("new post from %s: %s", mine[0].Feed, mine[0].Title)
+	} else {
+		t.status = fmt.Sprintf("%d new posts", len(mine))
+	}
+	return nil
+}
+
+// The layout: sources on the left, posts on the right, and when an
+// article is open the posts get the top third and the article the rest.
+// The last line is the status bar. Below tuiMinWidth by tuiMinHeight
+// cells there is no room for that and nothing is drawn.
+
+const (
+	tuiMinWidth  = 40
+	tuiMinHeight = 10
+)
+
+func (t *tui) sourcesWidth() int {
+	w, _ := t.scr.Size()
+	return clamp(w/4, 16, 32)
+}
+
+func (t *tui) postsArea() (x, y, width, height int) {
+	w, h := t.scr.Size()
+	x = t.sourcesWidth() + 1
+	height = h - 2
+	if t.opened.ID != uuid.Nil {
+		height = max(3, (h-2)/3)
+	}
+	return x, 1, w - x, height
+}
+
+func (t *tui) articleArea() (x, y, width, height int) {
+	px, py, pw, ph := t.postsArea()
+	_, h := t.scr.Size()
+	y = py + ph + 1
+	return px, y, pw, h - 1 - y
+}
+
+// pageHeight is how far page up and down move in the focused pane.
+func (t *tui) pageHeight() int {
+	_, h := t.scr.Size()
+	switch t.focus {
+	case panePosts:
+		_, _, _, ph := t.postsArea()
+		return max(1, ph-1)
+	case paneArticle:
+		_, _, _, ah := t.articleArea()
+		return max(1, ah-1)
+	}
+	return max(1, h-3)
+}
+
+func (t *tui) draw() {
+	scr := t.scr
+	scr.Clear()
+	w, h := scr.Size()
+	if w < tuiMinWidth || h < tuiMinHeight {
+		// the panes don't fit, wait for the terminal to grow
+		scr.Text(0, 0, w, "terminal too small", 0)
+		scr.Show()
+		return
+	}
+	sw := t.sourcesWidth()
+
+	title := t.sources[t.source].label
+	if t.unreadOnly {
+		title += " (unread)"
+	}
+	scr.Fill(0, 0, w, ' ', screen.Reverse)
+	scr.Text(1, 0, sw-1, "gator · "+t.user.Name, screen.Bold|screen.Reverse)
+	scr.Text(sw+1, 0, w-sw-1, title, screen.Bold|screen.Reverse)
+	for y := 1; y < h-1; y++ {
+		scr.Text(sw, y, 1, "│", screen.Dim)
+	}
+
+	t.drawSources(sw, h-2)
+	t.drawPosts()
+	if t.opened.ID != uuid.Nil {
+		t.drawArticle()
+	}
+
+	status := t.status
+	if status == "" {
+		status = tuiHelp
+	}
+	scr.Fill(0, h-1, w, ' ', screen.Reverse)
+	scr.Text(1, h-1, w-2, status, screen.Reverse)
+	scr.Show()
+}
+
+func (t *tui) drawSources(width, height int) {
+	t.sourceTop = scrollTop(t.source, t.sourceTop, height)
+	for i := t.sourceTop; i < len(t.sources) && i-t.sourceTop < height; i++ {
+		src := t.sources[i]
+		y := 1 + i - t.sourceTop
+		style := screen.Style(0)
+		if src.unread > 0 {
+			style = screen.Bold
+		}
+		if i == t.source {
+			style |= selectedStyle(t.focus == paneSources)
+			t.scr.Fill(0, y, width, ' ', style)
+		}
+		label := src.label
+		if src.indent {
+			label = "  " + label
+		}
+		count := ""
+		if src.unread > 0 {
+			count = fmt.Sprint(src.unread)
+		}
+		t.scr.Text(1, y, width-len(count)-3, label, style)
+		t.scr.Text(width-len(count)-1, y, len(count), count, style)
+	}
+}
+
+func (t *tui) drawPosts() {
+	x, y, width, height := t.postsArea()
+	if len(t.posts) == 0 {
+		t.scr.Text(x+1, y, width-1, "no posts", screen.Dim)
+		return
+	}
+	showFeed := t.sources[t.source].feed == ""
+	t.postTop = scrollTop(t.post, t.postTop, height)
+	for i := t.postTop; i < len(t.posts) && i-t.postTop < height; i++ {
+		p := t.posts[i]
+		row := y + i - t.postTop
+		style := screen.Style(0)
+		if !p.read {
+			style = screen.Bold
+		}
+		if i == t.post {
+			style |= selectedStyle(t.focus == panePosts)
+			t.scr.Fill(x, row, width, ' ', style)
+		}
+		flags := []rune("   ")
+		if !p.read {
+			flags[0] = 'N'
+		}
+		if p.starred {
+			flags[1] = '*'
+		}
+		col := t.scr.Text(x+1, row, width-1, string(flags), style)
+		col = t.scr.Text(col, row, x+width-col, postTime(p.PublishedAt, p.CreatedAt).Format("Jan 02")+"  ", style)
+		col = t.scr.Text(col, row, x+width-col, p.Title, style)
+		if showFeed {
+			t.scr.Text(col, row, x+width-col, " · "+p.FeedName, style|screen.Dim)
+		}
+	}
+}
+
+func (t *tui) drawArticle() {
+	x, y, width, height := t.articleArea()
+	t.scr.Text(x, y-1, width, strings.Repeat("─", max(0, width)), screen.Dim)
+	if width != t.articleWidth {
+		// the terminal was resized, wrap the text again
+		t.renderArticle()
+	}
+	t.scroll = clamp(t.scroll, 0, max(0, len(t.article)-1))
+	for i := t.scroll; i < len(t.article) && i-t.scroll < height; i++ {
+		style := screen.Style(0)
+		switch i {
+		case 0:
+			style = screen.Bold
+		case 1, 2:
+			style = screen.Dim
+		}
+		t.scr.Text(x+1, y+i-t.scroll, width-2, t.article[i], style)
+	}
+	if t.focus == paneArticle && len(t.article) > height {
+		pct := 100 * min(t.scroll+height, len(t.article)) / len(t.article)
+		label := fmt.Sprintf(" %d%% ", pct)
+		t.scr.Text(x+width-len(label)-1, y-1, len(label), label, screen.Dim)
+	}
+}
+
+// selectedStyle highlights the selection strongly in the focused pane and
+// faintly in the others.
+func selectedStyle(focused bool) screen.Style {
+	if focused {
+		return screen.Reverse
+	}
+	return screen.Underline
+}
+
+// scrollTop returns the first line to show so that the selected line is
+// visible in a pane of height lines, moving as little as possible.
+func scrollTop(selected, top, height int) int {
+	if selected < top {
+		return selected
+	}
+	if selected >= top+height {
+		return selected - height + 1
+	}
+	return top
+}
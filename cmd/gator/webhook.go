@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const webhookUsage = "usage: gator webhook <add <name> <url> [--feed url] [--folder name] [--keyword word]|list|test <name>|log <name>|remove <name>>"
+
+const (
+	// maxWebhookAttempts is how often a delivery is tried before it is
+	// marked failed. Retries back off from a minute, doubling each time.
+	maxWebhookAttempts = 6
+	webhookRetryDelay  = time.Minute
+	// webhookBatch is how many due deliveries gator agg sends per tick,
+	// webhookWorkers how many of them at once.
+	webhookBatch   = 50
+	webhookWorkers = 8
+	// webhookInterval is how often gator agg looks for due deliveries. It
+	// does so apart from fetching feeds, so slow receivers don't hold
+	// that up.
+	webhookInterval = 15 * time.Second
+)
+
+func handlerWebhook(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return errors.New(webhookUsage)
+	}
+	sub, args := cmd.args[0], cmd.args[1:]
+
+	switch {
+	case sub == "add":
+		return addWebhook(s, user, args)
+	case sub == "list" && len(args) == 0:
+		return listWebhooks(s, user)
+	case sub == "test" && len(args) == 1:
+		return testWebhook(s, user, args[0])
+	case sub == "log" && len(args) == 1:
+		return webhookLog(s, user, args[0])
+	case sub == "remove" && len(args) == 1:
+		n, err := s.db.DeleteWebhook(context.Background(), database.DeleteWebhookParams{UserID: user.ID, Name: args[0]})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("no webhook named %s", args[0])
+		}
+		return s.out.report(removedRow{Removed: args[0]}, "removed webhook %s", args[0])
+	}
+	return errors.New(webhookUsage)
+}
+
+type newWebhookRow struct {
+	Name   string `json:"name"`
+	Url    string `json:"url"`
+	Secret string `json:"secret"`
+}
+
+func addWebhook(s *state, user database.User, args []string) error {
+	fs := newFlagSet("webhook add")
+	feedURL := fs.String("feed", "", "only posts from this feed")
+	folder := fs.String("folder", "", "only posts from feeds in this folder")
+	keyword := fs.String("keyword", "", "only posts mentioning this word")
+	rest, err := parseFlags(fs, args)
+	if err != nil || len(rest) != 2 {
+		return errors.New(webhookUsage)
+	}
+	name, target := rest[0], rest[1]
+	if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s is not an http(s) url", target)
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return err
+	}
+	params := database.CreateWebhookParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, Name: name, Url: target, Secret: secret}
+	if *keyword != "" {
+		params.Keyword = sql.NullString{String: *keyword, Valid: true}
+	}
+	if *feedURL != "" {
+		feed, err := s.db.GetFeedByUrl(context.Background(), *feedURL)
+		if err != nil {
+			return fmt.Errorf("no feed with url %s", *feedURL)
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	if *folder != "" {
+		f, err := s.db.GetFolderByName(context.Background(), database.GetFolderByNameParams{UserID: user.ID, Name: *folder})
+		if err != nil {
+			return fmt.Errorf("no folder named %s", *folder)
+		}
+		params.FolderID = uuid.NullUUID{UUID: f.ID, Valid: true}
+	}
+
+	_, err = s.db.CreateWebhook(context.Background(), params)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("you already have a webhook named %s", name)
+		}
+		return err
+	}
+	return s.out.report(newWebhookRow{Name: name, Url: target, Secret: secret},
+		"added webhook %s\ndeliveries are signed with this secret in the %s header:\n%s", name, webhook.SignatureHeader, secret)
+}
+
+type webhookRow struct {
+	Name      string `json:"name"`
+	Url       string `json:"url"`
+	Feed      string `json:"feed"`
+	Folder    string `json:"folder"`
+	Keyword   string `json:"keyword"`
+	Delivered int64  `json:"delivered"`
+	Failed    int64  `json:"failed"`
+}
+
+func listWebhooks(s *state, user database.User) error {
+	hooks, err := s.db.GetWebhooksForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+	rows := []webhookRow{}
+	for _, h := range hooks {
+		rows = append(rows, webhookRow{Name: h.Name, Url: h.Url, Feed: h.FeedUrl.String, Folder: h.FolderName.String, Keyword: h.Keyword.String, Delivered: h.Delivered, Failed: h.Failed})
+	}
+	return s.out.print(rows)
+}
+
+type webhookTestRow struct {
+	Url          string `json:"url"`
+	ResponseCode int    `json:"response_code"`
+}
+
+// testWebhook sends a sample post right away, so a receiver can be checked
+// without waiting for a matching post.
+func testWebhook(s *state, user database.User, name string) error {
+	hook, err := s.db.GetWebhook(context.Background(), database.GetWebhookParams{UserID: user.ID, Name: name})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no webhook named %s", name)
+	}
+	if err != nil {
+		return err
+	}
+	payload := webhook.Payload{
+		Event:   "test",
+		Webhook: hook.Name,
+		Post: webhook.Post{
+			ID:         uuid.New(),
+			Title:      "gator webhook test",
+			Url:        "https://example.com/gator-webhook-test",
+			Feed:       "gator",
+			Categories: []string{},
+			CreatedAt:  time.Now(),
+		},
+	}
+	id := uuid.New()
+	code, sendErr := webhook.Send(context.Background(), hook.Url, hook.Secret, id, payload)
+
+	entry := database.LogWebhookDeliveryParams{ID: id, CreatedAt: time.Now(), UpdatedAt: time.Now(), WebhookID: hook.ID, Status: "delivered", Attempts: 1, NextAttemptAt: time.Now()}
+	if code != 0 {
+		entry.ResponseCode = sql.NullInt32{Int32: int32(code), Valid: true}
+	}
+	if sendErr != nil {
+		entry.Status = "failed"
+		entry.Error = sql.NullString{String: sendErr.Error(), Valid: true}
+	}
+	if err := s.db.LogWebhookDelivery(context.Background(), entry); err != nil {
+		return err
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+	return s.out.report(webhookTestRow{Url: hook.Url, ResponseCode: code}, "%s answered %d", hook.Url, code)
+}
+
+type deliveryRow struct {
+	Time         time.Time `json:"time"`
+	Status       string    `json:"status"`
+	Attempts     int32     `json:"attempts"`
+	ResponseCode *int32    `json:"response_code"`
+	Post         string    `json:"post"`
+	Error        string    `json:"error"`
+}
+
+func webhookLog(s *state, user database.User, name string) error {
+	hook, err := s.db.GetWebhook(context.Background(), database.GetWebhookParams{UserID: user.ID, Name: name})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no webhook named %s", name)
+	}
+	if err != nil {
+		return err
+	}
+	deliveries, err := s.db.GetWebhookDeliveries(context.Background(), database.GetWebhookDeliveriesParams{WebhookID: hook.ID, MaxResults: 20})
+	if err != nil {
+		return err
+	}
+	rows := []deliveryRow{}
+	for _, d := range deliveries {
+		title := d.PostTitle.String
+		if !d.PostTitle.Valid {
+			title = "(test)"
+		}
+		row := deliveryRow{Time: d.UpdatedAt, Status: d.Status, Attempts: d.Attempts, Post: title, Error: d.Error.String}
+		if d.ResponseCode.Valid {
+			row.ResponseCode = &d.ResponseCode.Int32
+		}
+		rows = append(rows, row)
+	}
+	return s.out.print(rows)
+}
+
+// runWebhooks delivers webhooks every interval for gator agg, until the
+// process exits.
+func runWebhooks(s *state, interval time.Duration) {
+	for ticker := time.NewTicker(interval); ; <-ticker.C {
+		if err := deliverWebhooks(s); err != nil {
+			fmt.Printf("could not deliver webhooks: %v\n", err)
+		}
+	}
+}
+
+// deliverWebhooks sends the deliveries that are due, queued by scrapeFeeds
+// for new posts matching a webhook, and schedules retries for the ones
+// that fail. Up to webhookWorkers are sent at once.
+func deliverWebhooks(s *state) error {
+	due, err := s.db.GetDueWebhookDeliveries(context.Background(), database.GetDueWebhookDeliveriesParams{Now: time.Now(), MaxResults: webhookBatch})
+	if err != nil {
+		return err
+	}
+	errs := make([]error, len(due))
+	sem := make(chan struct{}, webhookWorkers)
+	var wg sync.WaitGroup
+	for i, d := range due {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			errs[i] = deliverWebhook(s, d)
+			<-sem
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func deliverWebhook(s *state, d database.GetDueWebhookDeliveriesRow) error {
+	payload := webhook.Payload{
+		Event:   "post",
+		Webhook: d.WebhookName,
+		Post: webhook.Post{
+			ID:          d.PostID,
+			Title:       d.Title,
+			Url:         d.Url,
+			Feed:        d.FeedName,
+			FeedUrl:     d.FeedUrl,
+			Author:      d.Author.String,
+			Categories:  d.Categories,
+			Description: d.Description.String,
+			CreatedAt:   d.CreatedAt,
+		},
+	}
+	if payload.Post.Categories == nil {
+		payload.Post.Categories = []string{}
+	}
+	if d.PublishedAt.Valid {
+		payload.Post.PublishedAt = &d.PublishedAt.Time
+	}
+	code, sendErr := webhook.Send(context.Background(), d.WebhookUrl, d.Secret, d.ID, payload)
+
+	update := database.UpdateWebhookDeliveryParams{ID: d.ID, Status: "delivered", Attempts: d.Attempts + 1, NextAttemptAt: time.Now(), UpdatedAt: time.Now()}
+	if code != 0 {
+		update.ResponseCode = sql.NullInt32{Int32: int32(code), Valid: true}
+	}
+	if sendErr != nil {
+		update.Error = sql.NullString{String: sendErr.Error(), Valid: true}
+		update.Status = "pending"
+		update.NextAttemptAt = time.Now().Add(webhookRetryDelay << d.Attempts)
+		if update.Attempts >= maxWebhookAttempts {
+			update.Status = "failed"
+		}
+	}
+	return s.db.UpdateWebhookDelivery(context.Background(), update)
+}
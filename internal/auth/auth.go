@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// tokenPrefix marks gator personal access tokens so they are easy to spot
+// in config files and logs.
+const tokenPrefix = "gator_"
+
+var ErrNoAuthHeader = errors.New("no authorization header included in request")
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func CheckPasswordHash(password, hash string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+// MakeToken returns a new random personal access token. Only its hash is
+// stored, so it has to be shown to the user straight away.
+func MakeToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return tokenPrefix + hex.EncodeToString(b), nil
+}
+
+// HashToken returns the value stored in api_tokens.token_hash for a token.
+// Tokens are long and random, so a plain SHA-256 is enough and lets them
+// be looked up by hash.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// FeverKey is the api_key Fever clients send, md5("name:password") in
+// hex. It is only as strong as md5, which is why the Fever API is opt-in.
+func FeverKey(name, password string) string {
+	sum := md5.Sum([]byte(name + ":" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+func GetBearerToken(headers http.Header) (string, error) {
+	authHeader := headers.Get("Authorization")
+	if authHeader == "" {
+		return "", ErrNoAuthHeader
+	}
+	token, ok := strings.CutPrefix(authHeader, "Bearer ")
+	if !ok || token == "" {
+		return "", errors.New("malformed authorization header")
+	}
+	return token, nil
+}
@@ -16,16 +16,55 @@ func getConfigFilePath() (string, error) {
 	}
 
 	file := dir + "/.gatorconfig.json"
-	return file ,nil
+	return file, nil
 
 }
-type Config struct{
-	Url string `json:"db_url"`
+
+type Config struct {
+	Url      string `json:"db_url"`
 	Username string `json:"current_user_name"`
+
+	// Retention settings apply to every feed that does not override them,
+	// zero disables the limit.
+	RetentionMaxAgeDays     int `json:"retention_max_age_days,omitempty"`
+	RetentionMaxPosts       int `json:"retention_max_posts,omitempty"`
+	RetentionKeepUnreadDays int `json:"retention_keep_unread_days,omitempty"`
+
+	// Token is a personal access token that identifies the user instead of
+	// current_user_name. RequireToken refuses to run commands without one.
+	Token        string `json:"token,omitempty"`
+	RequireToken bool   `json:"require_token,omitempty"`
+
+	// SMTP is the mail server email digests are sent through, digests
+	// are not sent without one.
+	SMTP *SMTP `json:"smtp,omitempty"`
+
+	// Hooks are shell commands gator agg runs for new posts, at most
+	// HookConcurrency at a time.
+	Hooks           []Hook `json:"hooks,omitempty"`
+	HookConcurrency int    `json:"hook_concurrency,omitempty"`
+}
+
+// Hook runs Command for new posts in the feeds the current user follows
+// with notifications on, narrowed to one feed url or folder when set.
+type Hook struct {
+	Name           string `json:"name"`
+	Command        string `json:"command"`
+	Feed           string `json:"feed,omitempty"`
+	Folder         string `json:"folder,omitempty"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
 }
 
-func Read() (Config, error){
-	file, err:= getConfigFilePath()
+type SMTP struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	From     string `json:"from"`
+}
+
+func Read() (Config, error) {
+	file, err := getConfigFilePath()
 	if err != nil {
 		return Config{}, err
 	}
@@ -42,7 +81,16 @@ func Read() (Config, error){
 
 func (c *Config) SetUser(username string) error {
 	c.Username = username
-	data, err:= json.MarshalIndent(c, "", "  ")
+	return c.write()
+}
+
+func (c *Config) SetToken(token string) error {
+	c.Token = token
+	return c.write()
+}
+
+func (c *Config) write() error {
+	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
 		return err
 	}
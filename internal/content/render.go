@@ -0,0 +1,353 @@
+package content
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// RenderText turns the HTML in s into plain text for the terminal:
+// paragraphs, lists, quotes and code blocks keep their shape, lines are
+// wrapped at width (0 leaves them long) and links become numbered
+// footnotes listed at the end.
+func RenderText(s string, width int) string {
+	return render(s, width, false)
+}
+
+// RenderMarkdown is RenderText producing Markdown, with emphasis, headings
+// and code kept and links written as numbered references.
+func RenderMarkdown(s string, width int) string {
+	return render(s, width, true)
+}
+
+func render(s string, width int, markdown bool) string {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(Sanitize(s)), context)
+	if err != nil {
+		return Text(s)
+	}
+	r := &renderer{markdown: markdown, width: width}
+	for _, n := range nodes {
+		r.node(n)
+	}
+	r.flush()
+
+	out := strings.Join(r.blocks, "")
+	out = strings.Trim(out, "\n")
+	if len(r.links) > 0 {
+		out += "\n\n"
+		for i, link := range r.links {
+			if markdown {
+				out += fmt.Sprintf("[%d]: <%s>\n", i+1, link)
+			} else {
+				out += fmt.Sprintf("[%d] %s\n", i+1, link)
+			}
+		}
+		out = strings.TrimRight(out, "\n")
+	}
+	return out
+}
+
+// renderer walks a sanitized fragment collecting inline text into the
+// current paragraph and writing finished paragraphs to blocks.
+type renderer struct {
+	markdown bool
+	width    int
+
+	blocks []string
+	links  []string
+
+	inline strings.Builder
+	// quote and indent apply to every line of the paragraph, marker
+	// only to its first, like a list bullet
+	quote  int
+	indent int
+	marker string
+	// tight paragraphs, list items, aren't separated by a blank line
+	tight bool
+	pre   bool
+}
+
+func (r *renderer) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		r.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		r.flushLine()
+	case atom.Hr:
+		r.flush()
+		r.emit("---", false)
+	case atom.B, atom.Strong:
+		r.wrapInline(n, "**")
+	case atom.I, atom.Em:
+		r.wrapInline(n, "*")
+	case atom.Del, atom.S:
+		r.wrapInline(n, "~~")
+	case atom.Code:
+		if r.pre {
+			r.children(n)
+		} else {
+			r.wrapInline(n, "`")
+		}
+	case atom.A:
+		r.link(n)
+	case atom.Img:
+		r.image(n)
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.flush()
+		if r.markdown {
+			r.inline.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		}
+		r.children(n)
+		r.flush()
+	case atom.Ul, atom.Ol:
+		r.list(n)
+	case atom.Blockquote:
+		r.flush()
+		r.quote++
+		r.children(n)
+		r.flush()
+		r.quote--
+	case atom.Pre:
+		r.preformatted(n)
+	case atom.Td, atom.Th:
+		r.children(n)
+		r.inline.WriteString(" | ")
+	case atom.P, atom.Div, atom.Figure, atom.Figcaption, atom.Table, atom.Tr, atom.Dl, atom.Dt, atom.Dd:
+		r.flush()
+		r.children(n)
+		r.flush()
+	default:
+		r.children(n)
+	}
+}
+
+func (r *renderer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.node(c)
+	}
+}
+
+func (r *renderer) text(s string) {
+	if r.pre {
+		r.inline.WriteString(s)
+		return
+	}
+	// collapse whitespace the way a browser would
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		if s != "" && r.inline.Len() > 0 {
+			r.inline.WriteString(" ")
+		}
+		return
+	}
+	if startsWithSpace(s) && r.inline.Len() > 0 {
+		r.inline.WriteString(" ")
+	}
+	if r.markdown {
+		for i, w := range words {
+			words[i] = markdownEscaper.Replace(w)
+		}
+	}
+	r.inline.WriteString(strings.Join(words, " "))
+	if endsWithSpace(s) {
+		r.inline.WriteString(" ")
+	}
+}
+
+func (r *renderer) wrapInline(n *html.Node, mark string) {
+	if !r.markdown {
+		r.children(n)
+		return
+	}
+	r.inline.WriteString(mark)
+	r.children(n)
+	r.inline.WriteString(mark)
+}
+
+func (r *renderer) link(n *html.Node) {
+	href := attr(n, "href")
+	if href == "" || strings.HasPrefix(href, "#") {
+		r.children(n)
+		return
+	}
+	r.links = append(r.links, href)
+	if r.markdown {
+		r.inline.WriteString("[")
+		r.children(n)
+		fmt.Fprintf(&r.inline, "][%d]", len(r.links))
+		return
+	}
+	r.children(n)
+	fmt.Fprintf(&r.inline, " [%d]", len(r.links))
+}
+
+func (r *renderer) image(n *html.Node) {
+	alt := attr(n, "alt")
+	src := attr(n, "src")
+	if src == "" {
+		return
+	}
+	r.links = append(r.links, src)
+	if r.markdown {
+		fmt.Fprintf(&r.inline, "![%s][%d]", markdownEscaper.Replace(alt), len(r.links))
+		return
+	}
+	if alt == "" {
+		alt = "image"
+	}
+	fmt.Fprintf(&r.inline, "[%s] [%d]", alt, len(r.links))
+}
+
+func (r *renderer) list(n *html.Node) {
+	r.flush()
+	number := 1
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			r.node(c)
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		r.marker = marker
+		r.tight = true
+		r.indent += len(marker)
+		r.children(c)
+		r.flush()
+		r.indent -= len(marker)
+		r.marker = ""
+	}
+	r.tight = false
+}
+
+func (r *renderer) preformatted(n *html.Node) {
+	r.flush()
+	r.pre = true
+	r.children(n)
+	r.pre = false
+	body := strings.Trim(r.inline.String(), "\n")
+	r.inline.Reset()
+	if r.markdown {
+		body = "```\n" + body + "\n```"
+	} else {
+		lines := strings.Split(body, "\n")
+		for i, l := range lines {
+			lines[i] = "    " + l
+		}
+		body = strings.Join(lines, "\n")
+	}
+	r.emit(body, false)
+}
+
+// flushLine ends the current line for a <br> without starting a new
+// paragraph.
+func (r *renderer) flushLine() {
+	if r.markdown {
+		r.inline.WriteString("\\\n")
+	} else {
+		r.inline.WriteString("\n")
+	}
+}
+
+// flush ends the current paragraph and wraps it.
+func (r *renderer) flush() {
+	text := strings.TrimSpace(r.inline.String())
+	r.inline.Reset()
+	if text == "" {
+		return
+	}
+	var lines []string
+	for _, l := range strings.Split(text, "\n") {
+		lines = append(lines, wrap(strings.TrimSpace(l), r.width-r.indent-2*r.quote)...)
+	}
+	marker := r.marker
+	r.marker = ""
+	for i, l := range lines {
+		if i == 0 && marker != "" {
+			lines[i] = strings.Repeat(" ", r.indent-len(marker)) + marker + l
+		} else {
+			lines[i] = strings.Repeat(" ", r.indent) + l
+		}
+	}
+	r.emit(strings.Join(lines, "\n"), r.tight)
+}
+
+// emit adds a finished block, quoting every line when inside a
+// blockquote.
+func (r *renderer) emit(block string, tight bool) {
+	if r.quote > 0 {
+		prefix := strings.Repeat("> ", r.quote)
+		lines := strings.Split(block, "\n")
+		for i, l := range lines {
+			lines[i] = strings.TrimRight(prefix+l, " ")
+		}
+		block = strings.Join(lines, "\n")
+	}
+	sep := "\n\n"
+	if tight {
+		sep = "\n"
+	}
+	r.blocks = append(r.blocks, block+sep)
+}
+
+// wrap breaks s into lines of at most width runes at spaces. Words longer
+// than width, like URLs, get a line of their own.
+func wrap(s string, width int) []string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return []string{s}
+	}
+	var lines []string
+	var line strings.Builder
+	n := 0
+	for _, w := range strings.Fields(s) {
+		wl := utf8.RuneCountInString(w)
+		if n > 0 && n+1+wl > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			n = 0
+		}
+		if n > 0 {
+			line.WriteString(" ")
+			n++
+		}
+		line.WriteString(w)
+		n += wl
+	}
+	if n > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `<`, `\<`)
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && strings.ContainsRune(" \t\n\r\f", rune(s[0]))
+}
+
+func endsWithSpace(s string) bool {
+	return s != "" && strings.ContainsRune(" \t\n\r\f", rune(s[len(s)-1]))
+}
@@ -0,0 +1,197 @@
+package content
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRenderText(t *testing.T) {
+	tests := []struct {
+		name  string
+		html  string
+		width int
+		want  string
+	}{
+		{
+			name: "paragraphs",
+			html: "<p>one</p><p>two</p>",
+			want: "one\n\ntwo",
+		},
+		{
+			name: "whitespace collapses",
+			html: "<p>  spread \n\t out  </p>",
+			want: "spread out",
+		},
+		{
+			name: "inline markup dropped",
+			html: "<p><b>bold</b>, <em>em</em> and <code>code</code></p>",
+			want: "bold, em and code",
+		},
+		{
+			name: "links become footnotes",
+			html: `<p>see <a href="https://a.example">this</a> and <a href="https://b.example">that</a></p>`,
+			want: "see this [1] and that [2]\n\n[1] https://a.example\n[2] https://b.example",
+		},
+		{
+			name: "fragment links stay text",
+			html: `<p><a href="#top">top</a></p>`,
+			want: "top",
+		},
+		{
+			name: "images",
+			html: `<p><img src="https://a.example/x.png" alt="chart"></p>`,
+			want: "[chart] [1]\n\n[1] https://a.example/x.png",
+		},
+		{
+			name: "unordered list",
+			html: "<ul><li>a</li><li>b</li></ul>",
+			want: "- a\n- b",
+		},
+		{
+			name: "ordered list",
+			html: "<ol><li>a</li><li>b</li></ol>",
+			want: "1. a\n2. b",
+		},
+		{
+			name: "nested list",
+			html: "<ul><li>a<ul><li>b</li></ul></li></ul>",
+			want: "- a\n  - b",
+		},
+		{
+			name: "blockquote",
+			html: "<blockquote><p>quoted</p></blockquote>",
+			want: "> quoted",
+		},
+		{
+			name: "pre keeps lines",
+			html: "<pre>a  b\n  c</pre>",
+			want: "    a  b\n      c",
+		},
+		{
+			name: "line break",
+			html: "<p>one<br>two</p>",
+			want: "one\ntwo",
+		},
+		{
+			name: "heading",
+			html: "<h2>Title</h2><p>body</p>",
+			want: "Title\n\nbody",
+		},
+		{
+			name:  "wrapped",
+			html:  "<p>the quick brown fox jumps</p>",
+			width: 10,
+			want:  "the quick\nbrown fox\njumps",
+		},
+		{
+			name:  "wrapped list items indent",
+			html:  "<ul><li>the quick brown fox</li></ul>",
+			width: 12,
+			want:  "- the quick\n  brown fox",
+		},
+		{
+			name: "scripts removed",
+			html: "<p>safe</p><script>alert(1)</script>",
+			want: "safe",
+		},
+		{
+			name: "plain text",
+			html: "just text",
+			want: "just text",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderText(tt.html, tt.width); got != tt.want {
+				t.Errorf("RenderText(%q, %d) = %q, want %q", tt.html, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "emphasis",
+			html: "<p><strong>bold</strong> and <em>em</em> and <del>gone</del></p>",
+			want: "**bold** and *em* and ~~gone~~",
+		},
+		{
+			name: "inline code",
+			html: "<p>run <code>go test</code></p>",
+			want: "run `go test`",
+		},
+		{
+			name: "headings",
+			html: "<h1>One</h1><h3>Three</h3>",
+			want: "# One\n\n### Three",
+		},
+		{
+			name: "reference links",
+			html: `<p>see <a href="https://a.example">this</a></p>`,
+			want: "see [this][1]\n\n[1]: <https://a.example>",
+		},
+		{
+			name: "images",
+			html: `<img src="https://a.example/x.png" alt="chart">`,
+			want: "![chart][1]\n\n[1]: <https://a.example/x.png>",
+		},
+		{
+			name: "special characters escaped",
+			html: "<p>a_b *c* [d]</p>",
+			want: `a\_b \*c\* \[d\]`,
+		},
+		{
+			name: "code block",
+			html: "<pre><code>x := 1\ny := 2</code></pre>",
+			want: "```\nx := 1\ny := 2\n```",
+		},
+		{
+			name: "line break",
+			html: "<p>one<br>two</p>",
+			want: "one\\\ntwo",
+		},
+		{
+			name: "quoted list",
+			html: "<blockquote><ul><li>a</li><li>b</li></ul></blockquote>",
+			want: "> - a\n> - b",
+		},
+		{
+			name: "horizontal rule",
+			html: "<p>a</p><hr><p>b</p>",
+			want: "a\n\n---\n\nb",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderMarkdown(tt.html, 0); got != tt.want {
+				t.Errorf("RenderMarkdown(%q) = %q, want %q", tt.html, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  []string
+	}{
+		{"", 10, []string{""}},
+		{"short", 10, []string{"short"}},
+		{"no width keeps it long", 0, []string{"no width keeps it long"}},
+		{"exactly ten", 11, []string{"exactly ten"}},
+		{"one two three", 7, []string{"one two", "three"}},
+		{"a https://example.com/very/long b", 8, []string{"a", "https://example.com/very/long", "b"}},
+		{"héllo wörld", 5, []string{"héllo", "wörld"}},
+	}
+	for _, tt := range tests {
+		if got := wrap(tt.s, tt.width); !slices.Equal(got, tt.want) {
+			t.Errorf("wrap(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
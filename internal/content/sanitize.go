@@ -0,0 +1,125 @@
+// Package content cleans up the HTML feeds put in post descriptions and
+// bodies before gator shows it to anyone.
+package content
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowed maps the elements kept by Sanitize to the attributes they may
+// keep. Other elements are unwrapped, their text survives.
+var allowed = map[atom.Atom][]string{
+	atom.A: {"href", "title"}, atom.Abbr: {"title"}, atom.B: nil, atom.Blockquote: nil, atom.Br: nil,
+	atom.Code: nil, atom.Dd: nil, atom.Div: nil, atom.Del: nil, atom.Dl: nil, atom.Dt: nil, atom.Em: nil,
+	atom.Figcaption: nil, atom.Figure: nil, atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil,
+	atom.H5: nil, atom.H6: nil, atom.Hr: nil, atom.I: nil, atom.Img: {"src", "alt", "title", "width", "height"},
+	atom.Ins: nil, atom.Kbd: nil, atom.Li: nil, atom.Ol: nil, atom.P: nil, atom.Pre: nil, atom.Q: nil,
+	atom.S: nil, atom.Small: nil, atom.Span: nil, atom.Strong: nil, atom.Sub: nil, atom.Sup: nil,
+	atom.Table: nil, atom.Tbody: nil, atom.Td: {"colspan", "rowspan"}, atom.Tfoot: nil,
+	atom.Th: {"colspan", "rowspan"}, atom.Thead: nil, atom.Tr: nil, atom.U: nil, atom.Ul: nil,
+}
+
+// dropped elements are removed together with everything inside them.
+var dropped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true, atom.Embed: true,
+	atom.Noscript: true, atom.Template: true, atom.Svg: true, atom.Math: true, atom.Form: true,
+	atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true, atom.Head: true,
+	atom.Title: true, atom.Meta: true, atom.Link: true, atom.Base: true, atom.Frame: true, atom.Frameset: true,
+}
+
+// Sanitize returns s with everything but a small set of formatting
+// elements and safe attributes removed, so it can be embedded in a page.
+// Links only keep http, https, mailto and relative URLs and get
+// rel="nofollow noopener noreferrer".
+func Sanitize(s string) string {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(s), context)
+	if err != nil {
+		return html.EscapeString(s)
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		writeNode(&b, n)
+	}
+	return b.String()
+}
+
+func writeNode(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		writeChildren(b, n)
+		return
+	}
+
+	if dropped[n.DataAtom] {
+		return
+	}
+	attrs, ok := allowed[n.DataAtom]
+	if !ok || n.Namespace != "" {
+		writeChildren(b, n)
+		return
+	}
+
+	b.WriteString("<" + n.Data)
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !contains(attrs, a.Key) {
+			continue
+		}
+		if (a.Key == "href" || a.Key == "src") && !safeURL(a.Val, a.Key == "href") {
+			continue
+		}
+		b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+	}
+	if n.DataAtom == atom.A {
+		b.WriteString(` rel="nofollow noopener noreferrer"`)
+	}
+	b.WriteString(">")
+	if n.DataAtom == atom.Br || n.DataAtom == atom.Hr || n.DataAtom == atom.Img {
+		return
+	}
+	writeChildren(b, n)
+	b.WriteString("</" + n.Data + ">")
+}
+
+func writeChildren(b *strings.Builder, n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeNode(b, c)
+	}
+}
+
+// SafeURL reports whether raw is an http, https or relative URL, which a
+// page can link to without running anything.
+func SafeURL(raw string) bool {
+	return safeURL(raw, false)
+}
+
+func safeURL(raw string, allowMailto bool) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https":
+		return true
+	case "mailto":
+		return allowMailto
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
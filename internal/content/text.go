@@ -0,0 +1,56 @@
+package content
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Text returns the text of the HTML in s with tags removed and runs of
+// whitespace collapsed to one space, for places that can't show HTML.
+func Text(s string) string {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(s), context)
+	if err != nil {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		writeText(&b, n)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func writeText(b *strings.Builder, n *html.Node) {
+	if n.Type == html.TextNode {
+		b.WriteString(n.Data)
+		return
+	}
+	if n.Type == html.ElementNode && dropped[n.DataAtom] {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(b, c)
+	}
+	// keep words in neighbouring blocks apart
+	if n.Type == html.ElementNode && n.DataAtom != atom.A && n.DataAtom != atom.B && n.DataAtom != atom.Em &&
+		n.DataAtom != atom.I && n.DataAtom != atom.Span && n.DataAtom != atom.Strong && n.DataAtom != atom.Code {
+		b.WriteString(" ")
+	}
+}
+
+// Excerpt returns at most n runes of the text of s, cut at a word boundary
+// and marked with an ellipsis when it was shortened.
+func Excerpt(s string, n int) string {
+	text := Text(s)
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	cut := string(runes[:n])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
@@ -14,10 +14,10 @@ import (
 )
 
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
-    VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, site_url)
+    VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING
-    id, created_at, updated_at, name, url, user_id, last_fetched_at
+    id, created_at, updated_at, name, url, user_id, last_fetched_at, retention_max_age_days, retention_max_posts, site_url, int_id
 `
 
 type CreateFeedParams struct {
@@ -27,6 +27,7 @@ type CreateFeedParams struct {
 	Name      string
 	Url       string
 	UserID    uuid.UUID
+	SiteUrl   sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -37,6 +38,7 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.SiteUrl,
 	)
 	var i Feed
 	err := row.Scan(
@@ -47,13 +49,55 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.RetentionMaxAgeDays,
+		&i.RetentionMaxPosts,
+		&i.SiteUrl,
+		&i.IntID,
+	)
+	return i, err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const getFeed = `-- name: GetFeed :one
+SELECT
+    id, created_at, updated_at, name, url, user_id, last_fetched_at, retention_max_age_days, retention_max_posts, site_url, int_id
+FROM
+    feeds
+WHERE
+    id = $1
+`
+
+func (q *Queries) GetFeed(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeed, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.RetentionMaxAgeDays,
+		&i.RetentionMaxPosts,
+		&i.SiteUrl,
+		&i.IntID,
 	)
 	return i, err
 }
 
 const getFeedByUrl = `-- name: GetFeedByUrl :one
 SELECT
-    id, created_at, updated_at, name, url, user_id, last_fetched_at
+    id, created_at, updated_at, name, url, user_id, last_fetched_at, retention_max_age_days, retention_max_posts, site_url, int_id
 FROM
     feeds
 WHERE
@@ -71,13 +115,17 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.RetentionMaxAgeDays,
+		&i.RetentionMaxPosts,
+		&i.SiteUrl,
+		&i.IntID,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
 SELECT
-    f.id, f.created_at, f.updated_at, f.name, f.url, f.user_id, f.last_fetched_at,
+    f.id, f.created_at, f.updated_at, f.name, f.url, f.user_id, f.last_fetched_at, f.retention_max_age_days, f.retention_max_posts, f.site_url, f.int_id,
     u.name AS user_name
 FROM
     feeds f
@@ -85,14 +133,18 @@ FROM
 `
 
 type GetFeedsRow struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
-	UserName      string
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Name                string
+	Url                 string
+	UserID              uuid.UUID
+	LastFetchedAt       sql.NullTime
+	RetentionMaxAgeDays sql.NullInt32
+	RetentionMaxPosts   sql.NullInt32
+	SiteUrl             sql.NullString
+	IntID               int64
+	UserName            string
 }
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
@@ -112,6 +164,10 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.RetentionMaxAgeDays,
+			&i.RetentionMaxPosts,
+			&i.SiteUrl,
+			&i.IntID,
 			&i.UserName,
 		); err != nil {
 			return nil, err
@@ -129,7 +185,7 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
 SELECT
-    id, created_at, updated_at, name, url, user_id, last_fetched_at
+    id, created_at, updated_at, name, url, user_id, last_fetched_at, retention_max_age_days, retention_max_posts, site_url, int_id
 FROM
     feeds
 ORDER BY
@@ -148,6 +204,10 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.RetentionMaxAgeDays,
+		&i.RetentionMaxPosts,
+		&i.SiteUrl,
+		&i.IntID,
 	)
 	return i, err
 }
@@ -171,3 +231,71 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams
 	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.ID, arg.UpdatedAt)
 	return err
 }
+
+const reassignFeeds = `-- name: ReassignFeeds :exec
+UPDATE
+    feeds
+SET
+    user_id = $1,
+    updated_at = $2
+WHERE
+    user_id = $3
+`
+
+type ReassignFeedsParams struct {
+	ToUserID   uuid.UUID
+	UpdatedAt  time.Time
+	FromUserID uuid.UUID
+}
+
+func (q *Queries) ReassignFeeds(ctx context.Context, arg ReassignFeedsParams) error {
+	_, err := q.db.ExecContext(ctx, reassignFeeds, arg.ToUserID, arg.UpdatedAt, arg.FromUserID)
+	return err
+}
+
+const setFeedRetention = `-- name: SetFeedRetention :exec
+UPDATE
+    feeds
+SET
+    updated_at = $2,
+    retention_max_age_days = $3,
+    retention_max_posts = $4
+WHERE
+    id = $1
+`
+
+type SetFeedRetentionParams struct {
+	ID                  uuid.UUID
+	UpdatedAt           time.Time
+	RetentionMaxAgeDays sql.NullInt32
+	RetentionMaxPosts   sql.NullInt32
+}
+
+func (q *Queries) SetFeedRetention(ctx context.Context, arg SetFeedRetentionParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedRetention,
+		arg.ID,
+		arg.UpdatedAt,
+		arg.RetentionMaxAgeDays,
+		arg.RetentionMaxPosts,
+	)
+	return err
+}
+
+const setFeedSiteUrl = `-- name: SetFeedSiteUrl :exec
+UPDATE
+    feeds
+SET
+    site_url = $2
+WHERE
+    id = $1
+`
+
+type SetFeedSiteUrlParams struct {
+	ID      uuid.UUID
+	SiteUrl sql.NullString
+}
+
+func (q *Queries) SetFeedSiteUrl(ctx context.Context, arg SetFeedSiteUrlParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedSiteUrl, arg.ID, arg.SiteUrl)
+	return err
+}
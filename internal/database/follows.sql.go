@@ -15,12 +15,12 @@ import (
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH inserted AS (
-INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-        VALUES ($1, $2, $3, $4, $5)
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, display_name, notify, hidden)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
     RETURNING
-        id, created_at, updated_at, user_id, feed_id)
+        id, created_at, updated_at, user_id, feed_id, folder_id, display_name, notify, hidden)
     SELECT
-        inserted.id, inserted.created_at, inserted.updated_at, inserted.user_id, inserted.feed_id,
+        inserted.id, inserted.created_at, inserted.updated_at, inserted.user_id, inserted.feed_id, inserted.folder_id, inserted.display_name, inserted.notify, inserted.hidden,
         f.name AS feed_name,
         u.name AS user_name
     FROM
@@ -30,21 +30,28 @@ INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
 `
 
 type CreateFeedFollowParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	FeedID      uuid.UUID
+	DisplayName sql.NullString
+	Notify      bool
+	Hidden      bool
 }
 
 type CreateFeedFollowRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
-	FeedName  string
-	UserName  string
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	FeedID      uuid.UUID
+	FolderID    uuid.NullUUID
+	DisplayName sql.NullString
+	Notify      bool
+	Hidden      bool
+	FeedName    string
+	UserName    string
 }
 
 func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error) {
@@ -54,6 +61,9 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		arg.UpdatedAt,
 		arg.UserID,
 		arg.FeedID,
+		arg.DisplayName,
+		arg.Notify,
+		arg.Hidden,
 	)
 	var i CreateFeedFollowRow
 	err := row.Scan(
@@ -62,6 +72,10 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.FolderID,
+		&i.DisplayName,
+		&i.Notify,
+		&i.Hidden,
 		&i.FeedName,
 		&i.UserName,
 	)
@@ -83,21 +97,71 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 	return err
 }
 
+const getFeedFollow = `-- name: GetFeedFollow :one
+SELECT
+    id, created_at, updated_at, user_id, feed_id, folder_id, display_name, notify, hidden
+FROM
+    feed_follows
+WHERE
+    user_id = $1
+    AND feed_id = $2
+`
+
+type GetFeedFollowParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) GetFeedFollow(ctx context.Context, arg GetFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollow, arg.UserID, arg.FeedID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.FolderID,
+		&i.DisplayName,
+		&i.Notify,
+		&i.Hidden,
+	)
+	return i, err
+}
+
 const getFeedFollowForUser = `-- name: GetFeedFollowForUser :many
 SELECT
     f.name AS feed_name,
-    u.name AS useer_name
+    u.name AS useer_name,
+    f.url AS feed_url,
+    fo.name AS folder_name,
+    o.display_name,
+    o.notify,
+    o.hidden,
+    f.site_url,
+    o.feed_id
 FROM
     feed_follows o
     LEFT JOIN users u ON o.user_id = u.id
     LEFT JOIN feeds f ON o.feed_id = f.id
+    LEFT JOIN folders fo ON o.folder_id = fo.id
 WHERE
     u.id = $1
+ORDER BY
+    fo.name NULLS FIRST,
+    f.name
 `
 
 type GetFeedFollowForUserRow struct {
-	FeedName  sql.NullString
-	UseerName sql.NullString
+	FeedName    sql.NullString
+	UseerName   sql.NullString
+	FeedUrl     sql.NullString
+	FolderName  sql.NullString
+	DisplayName sql.NullString
+	Notify      bool
+	Hidden      bool
+	SiteUrl     sql.NullString
+	FeedID      uuid.UUID
 }
 
 func (q *Queries) GetFeedFollowForUser(ctx context.Context, id uuid.UUID) ([]GetFeedFollowForUserRow, error) {
@@ -109,7 +173,111 @@ func (q *Queries) GetFeedFollowForUser(ctx context.Context, id uuid.UUID) ([]Get
 	var items []GetFeedFollowForUserRow
 	for rows.Next() {
 		var i GetFeedFollowForUserRow
-		if err := rows.Scan(&i.FeedName, &i.UseerName); err != nil {
+		if err := rows.Scan(
+			&i.FeedName,
+			&i.UseerName,
+			&i.FeedUrl,
+			&i.FolderName,
+			&i.DisplayName,
+			&i.Notify,
+			&i.Hidden,
+			&i.SiteUrl,
+			&i.FeedID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnreadCountsForUser = `-- name: GetUnreadCountsForUser :many
+SELECT
+    ff.feed_id,
+    f.int_id AS feed_int_id,
+    COALESCE(ff.display_name, f.name) AS feed_name,
+    f.url AS feed_url,
+    f.site_url,
+    fo.name AS folder_name,
+    fo.int_id AS folder_int_id,
+    ff.hidden,
+    count(p.id) AS unread
+FROM
+    feed_follows ff
+    INNER JOIN feeds f ON ff.feed_id = f.id
+    LEFT JOIN folders fo ON ff.folder_id = fo.id
+    LEFT JOIN posts p ON p.feed_id = f.id
+        AND NOT EXISTS (
+            SELECT
+                1
+            FROM
+                read_posts rp
+            WHERE
+                rp.post_id = p.id
+                AND rp.user_id = ff.user_id)
+        AND NOT EXISTS (
+            SELECT
+                1
+            FROM
+                hidden_posts hp
+            WHERE
+                hp.post_id = p.id
+                AND hp.user_id = ff.user_id)
+WHERE
+    ff.user_id = $1
+GROUP BY
+    ff.feed_id,
+    f.int_id,
+    ff.display_name,
+    f.name,
+    f.url,
+    f.site_url,
+    fo.name,
+    fo.int_id,
+    ff.hidden
+ORDER BY
+    fo.name NULLS FIRST,
+    feed_name
+`
+
+type GetUnreadCountsForUserRow struct {
+	FeedID      uuid.UUID
+	FeedIntID   int64
+	FeedName    string
+	FeedUrl     string
+	SiteUrl     sql.NullString
+	FolderName  sql.NullString
+	FolderIntID sql.NullInt64
+	Hidden      bool
+	Unread      int64
+}
+
+func (q *Queries) GetUnreadCountsForUser(ctx context.Context, userID uuid.UUID) ([]GetUnreadCountsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUnreadCountsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUnreadCountsForUserRow
+	for rows.Next() {
+		var i GetUnreadCountsForUserRow
+		if err := rows.Scan(
+			&i.FeedID,
+			&i.FeedIntID,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.SiteUrl,
+			&i.FolderName,
+			&i.FolderIntID,
+			&i.Hidden,
+			&i.Unread,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -122,3 +290,68 @@ func (q *Queries) GetFeedFollowForUser(ctx context.Context, id uuid.UUID) ([]Get
 	}
 	return items, nil
 }
+
+const setFeedFollowFolder = `-- name: SetFeedFollowFolder :execrows
+UPDATE
+    feed_follows
+SET
+    folder_id = $3,
+    updated_at = $4
+WHERE
+    user_id = $1
+    AND feed_id = $2
+`
+
+type SetFeedFollowFolderParams struct {
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+	FolderID  uuid.NullUUID
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetFeedFollowFolder(ctx context.Context, arg SetFeedFollowFolderParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedFollowFolder,
+		arg.UserID,
+		arg.FeedID,
+		arg.FolderID,
+		arg.UpdatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateFeedFollowSettings = `-- name: UpdateFeedFollowSettings :exec
+UPDATE
+    feed_follows
+SET
+    display_name = $3,
+    notify = $4,
+    hidden = $5,
+    updated_at = $6
+WHERE
+    user_id = $1
+    AND feed_id = $2
+`
+
+type UpdateFeedFollowSettingsParams struct {
+	UserID      uuid.UUID
+	FeedID      uuid.UUID
+	DisplayName sql.NullString
+	Notify      bool
+	Hidden      bool
+	UpdatedAt   time.Time
+}
+
+func (q *Queries) UpdateFeedFollowSettings(ctx context.Context, arg UpdateFeedFollowSettingsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedFollowSettings,
+		arg.UserID,
+		arg.FeedID,
+		arg.DisplayName,
+		arg.Notify,
+		arg.Hidden,
+		arg.UpdatedAt,
+	)
+	return err
+}
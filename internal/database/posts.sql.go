@@ -11,13 +11,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-    VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content, author, categories)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING
-    id, created_at, updated_at, title, url, description, published_at, feed_id
+    id, created_at, updated_at, title, url, description, published_at, feed_id, content, search_vector, author, categories, int_id
 `
 
 type CreatePostParams struct {
@@ -29,6 +30,9 @@ type CreatePostParams struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Content     sql.NullString
+	Author      sql.NullString
+	Categories  []string
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -41,6 +45,9 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Content,
+		arg.Author,
+		pq.Array(arg.Categories),
 	)
 	var i Post
 	err := row.Scan(
@@ -52,41 +59,308 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Content,
+		&i.SearchVector,
+		&i.Author,
+		pq.Array(&i.Categories),
+		&i.IntID,
 	)
 	return i, err
 }
 
+const getPostForUser = `-- name: GetPostForUser :one
+SELECT
+    p.id,
+    p.int_id,
+    p.title,
+    p.url,
+    p.description,
+    p.content,
+    p.published_at,
+    p.created_at,
+    p.author,
+    p.categories,
+    COALESCE(ff.display_name, f.name) AS feed_name,
+    f.url AS feed_url,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            read_posts rp
+        WHERE
+            rp.post_id = p.id
+            AND rp.user_id = ff.user_id) AS is_read,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            saved_posts sp
+        WHERE
+            sp.post_id = p.id
+            AND sp.user_id = ff.user_id) AS is_starred
+FROM
+    posts p
+    INNER JOIN feeds f ON p.feed_id = f.id
+    INNER JOIN feed_follows ff ON ff.feed_id = f.id
+WHERE
+    p.id = $1
+    AND ff.user_id = $2
+`
+
+type GetPostForUserParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+type GetPostForUserRow struct {
+	ID          uuid.UUID
+	IntID       int64
+	Title       string
+	Url         string
+	Description sql.NullString
+	Content     sql.NullString
+	PublishedAt sql.NullTime
+	CreatedAt   time.Time
+	Author      sql.NullString
+	Categories  []string
+	FeedName    string
+	FeedUrl     string
+	IsRead      bool
+	IsStarred   bool
+}
+
+func (q *Queries) GetPostForUser(ctx context.Context, arg GetPostForUserParams) (GetPostForUserRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostForUser, arg.ID, arg.UserID)
+	var i GetPostForUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.IntID,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.Content,
+		&i.PublishedAt,
+		&i.CreatedAt,
+		&i.Author,
+		pq.Array(&i.Categories),
+		&i.FeedName,
+		&i.FeedUrl,
+		&i.IsRead,
+		&i.IsStarred,
+	)
+	return i, err
+}
+
+const getPostIDByIntID = `-- name: GetPostIDByIntID :one
+SELECT
+    id
+FROM
+    posts
+WHERE
+    int_id = $1
+`
+
+func (q *Queries) GetPostIDByIntID(ctx context.Context, intID int64) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getPostIDByIntID, intID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getPostStatesForUser = `-- name: GetPostStatesForUser :many
+SELECT
+    p.id,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            read_posts rp
+        WHERE
+            rp.post_id = p.id
+            AND rp.user_id = $1) AS is_read,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            saved_posts sp
+        WHERE
+            sp.post_id = p.id
+            AND sp.user_id = $1) AS is_starred
+FROM
+    posts p
+WHERE
+    p.id = ANY ($2::uuid[])
+`
+
+type GetPostStatesForUserParams struct {
+	UserID  uuid.UUID
+	PostIds []uuid.UUID
+}
+
+type GetPostStatesForUserRow struct {
+	ID        uuid.UUID
+	IsRead    bool
+	IsStarred bool
+}
+
+func (q *Queries) GetPostStatesForUser(ctx context.Context, arg GetPostStatesForUserParams) ([]GetPostStatesForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostStatesForUser, arg.UserID, pq.Array(arg.PostIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostStatesForUserRow
+	for rows.Next() {
+		var i GetPostStatesForUserRow
+		if err := rows.Scan(&i.ID, &i.IsRead, &i.IsStarred); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
 SELECT
+    p.id,
+    p.int_id,
     p.title,
     p.url,
     p.description,
-    p.published_at
+    p.published_at,
+    p.created_at,
+    p.author,
+    p.categories,
+    COALESCE(ff.display_name, f.name) AS feed_name
 FROM
     posts p
-    LEFT JOIN feeds f ON p.feed_id = f.id
-    LEFT JOIN users u ON f.user_id = u.id
+    INNER JOIN feeds f ON p.feed_id = f.id
+    INNER JOIN feed_follows ff ON ff.feed_id = f.id
 WHERE
-    u.id = $1
+    ff.user_id = $1
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            hidden_posts hp
+        WHERE
+            hp.post_id = p.id
+            AND hp.user_id = ff.user_id)
+    AND ($2::text IS NULL
+        OR f.url = $2)
+    AND (NOT ff.hidden
+        OR f.url = $2)
+    AND ($3::text IS NULL
+        OR ff.folder_id IN (
+            SELECT
+                fo.id
+            FROM
+                folders fo
+            WHERE
+                fo.user_id = ff.user_id
+                AND fo.name = $3))
+    AND ($4::timestamp IS NULL
+        OR COALESCE(p.published_at, p.created_at) >= $4)
+    AND ($5::timestamp IS NULL
+        OR COALESCE(p.published_at, p.created_at) < $5)
+    AND (NOT $6::boolean
+        OR NOT EXISTS (
+            SELECT
+                1
+            FROM
+                read_posts rp
+            WHERE
+                rp.post_id = p.id
+                AND rp.user_id = ff.user_id))
+    AND (NOT $7::boolean
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                saved_posts sp
+            WHERE
+                sp.post_id = p.id
+                AND sp.user_id = ff.user_id))
+    AND ($8::text IS NULL
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                unnest(p.categories) c
+            WHERE
+                lower(c) = lower($8)))
+    AND ($9::text IS NULL
+        OR p.author ILIKE '%' || $9 || '%')
+    AND ($10::text IS NULL
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                post_tags pt
+                INNER JOIN tags t ON t.id = pt.tag_id
+            WHERE
+                pt.post_id = p.id
+                AND t.user_id = ff.user_id
+                AND t.name = $10))
+    AND ($11::timestamp IS NULL
+        OR (p.created_at, p.id) < ($11, $12::uuid))
 ORDER BY
-    p.created_at DESC
-LIMIT $2
+    p.created_at DESC,
+    p.id DESC
+LIMIT $13
 `
 
 type GetPostsForUserParams struct {
-	ID    uuid.UUID
-	Limit int32
+	UserID         uuid.UUID
+	FeedUrl        sql.NullString
+	Folder         sql.NullString
+	Since          sql.NullTime
+	Until          sql.NullTime
+	UnreadOnly     bool
+	StarredOnly    bool
+	Category       sql.NullString
+	Author         sql.NullString
+	Tag            sql.NullString
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	MaxResults     int32
 }
 
 type GetPostsForUserRow struct {
+	ID          uuid.UUID
+	IntID       int64
 	Title       string
 	Url         string
 	Description sql.NullString
 	PublishedAt sql.NullTime
+	CreatedAt   time.Time
+	Author      sql.NullString
+	Categories  []string
+	FeedName    string
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.ID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.FeedUrl,
+		arg.Folder,
+		arg.Since,
+		arg.Until,
+		arg.UnreadOnly,
+		arg.StarredOnly,
+		arg.Category,
+		arg.Author,
+		arg.Tag,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.MaxResults,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -95,10 +369,294 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	for rows.Next() {
 		var i GetPostsForUserRow
 		if err := rows.Scan(
+			&i.ID,
+			&i.IntID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.FeedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPublishedPostsForUser = `-- name: GetPublishedPostsForUser :many
+SELECT
+    p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id, p.content, p.search_vector, p.author, p.categories, p.int_id
+FROM
+    posts p
+    INNER JOIN feed_follows ff ON ff.feed_id = p.feed_id
+WHERE
+    ff.user_id = $1
+    AND NOT ff.hidden
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            hidden_posts hp
+        WHERE
+            hp.post_id = p.id
+            AND hp.user_id = ff.user_id)
+    AND ($2::text IS NULL
+        OR ff.folder_id IN (
+            SELECT
+                fo.id
+            FROM
+                folders fo
+            WHERE
+                fo.user_id = ff.user_id
+                AND fo.name = $2))
+ORDER BY
+    p.created_at DESC,
+    p.id DESC
+LIMIT $3
+`
+
+type GetPublishedPostsForUserParams struct {
+	UserID     uuid.UUID
+	Folder     sql.NullString
+	MaxResults int32
+}
+
+func (q *Queries) GetPublishedPostsForUser(ctx context.Context, arg GetPublishedPostsForUserParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPublishedPostsForUser, arg.UserID, arg.Folder, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
 			&i.Title,
 			&i.Url,
 			&i.Description,
 			&i.PublishedAt,
+			&i.FeedID,
+			&i.Content,
+			&i.SearchVector,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.IntID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markPostRead = `-- name: MarkPostRead :exec
+INSERT INTO read_posts (id, created_at, updated_at, user_id, post_id)
+    VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, post_id)
+    DO NOTHING
+`
+
+type MarkPostReadParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+}
+
+func (q *Queries) MarkPostRead(ctx context.Context, arg MarkPostReadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostRead,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.PostID,
+	)
+	return err
+}
+
+const markPostUnread = `-- name: MarkPostUnread :exec
+DELETE FROM read_posts
+WHERE user_id = $1
+    AND post_id = $2
+`
+
+type MarkPostUnreadParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) MarkPostUnread(ctx context.Context, arg MarkPostUnreadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostUnread, arg.UserID, arg.PostID)
+	return err
+}
+
+const notifyNewPost = `-- name: NotifyNewPost :exec
+SELECT
+    pg_notify('gator_new_posts', $1::text)
+`
+
+func (q *Queries) NotifyNewPost(ctx context.Context, payload string) error {
+	_, err := q.db.ExecContext(ctx, notifyNewPost, payload)
+	return err
+}
+
+const prunePosts = `-- name: PrunePosts :execrows
+WITH ranked AS (
+    SELECT
+        p.id,
+        COALESCE(p.published_at, p.created_at) AS posted_at,
+        ROW_NUMBER() OVER (PARTITION BY p.feed_id ORDER BY COALESCE(p.published_at, p.created_at) DESC) AS position,
+        COALESCE(f.retention_max_age_days, $1::int) AS max_age_days,
+        COALESCE(f.retention_max_posts, $2::int) AS max_posts
+    FROM
+        posts p
+        INNER JOIN feeds f ON p.feed_id = f.id
+)
+DELETE FROM posts
+USING ranked r
+WHERE posts.id = r.id
+    AND ((r.max_age_days > 0
+            AND r.posted_at < NOW() - make_interval(days => r.max_age_days))
+        OR (r.max_posts > 0
+            AND r.position > r.max_posts))
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            saved_posts s
+        WHERE
+            s.post_id = posts.id)
+    AND NOT (posts.created_at > NOW() - make_interval(days => $3::int)
+        AND EXISTS (
+            SELECT
+                1
+            FROM
+                feed_follows ff
+            WHERE
+                ff.feed_id = posts.feed_id
+                AND NOT EXISTS (
+                    SELECT
+                        1
+                    FROM
+                        read_posts rp
+                    WHERE
+                        rp.post_id = posts.id
+                        AND rp.user_id = ff.user_id)))
+`
+
+type PrunePostsParams struct {
+	MaxAgeDays     int32
+	MaxPosts       int32
+	KeepUnreadDays int32
+}
+
+func (q *Queries) PrunePosts(ctx context.Context, arg PrunePostsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, prunePosts, arg.MaxAgeDays, arg.MaxPosts, arg.KeepUnreadDays)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchPostsForUser = `-- name: SearchPostsForUser :many
+SELECT
+    p.id,
+    p.int_id,
+    p.title,
+    p.url,
+    p.published_at,
+    COALESCE(ff.display_name, f.name) AS feed_name,
+    ts_rank(p.search_vector, q)::real AS rank,
+    ts_headline('english', COALESCE(p.description, p.content, ''), q, 'MaxFragments=2, StartSel=**, StopSel=**') AS headline
+FROM
+    posts p
+    INNER JOIN feeds f ON p.feed_id = f.id
+    INNER JOIN feed_follows ff ON ff.feed_id = f.id,
+    websearch_to_tsquery('english', $1) q
+WHERE
+    ff.user_id = $2
+    AND p.search_vector @@ q
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            hidden_posts hp
+        WHERE
+            hp.post_id = p.id
+            AND hp.user_id = ff.user_id)
+    AND ($3::text IS NULL
+        OR f.url = $3)
+    AND ($4::timestamp IS NULL
+        OR COALESCE(p.published_at, p.created_at) >= $4)
+ORDER BY
+    rank DESC,
+    COALESCE(p.published_at, p.created_at) DESC
+LIMIT $5
+`
+
+type SearchPostsForUserParams struct {
+	Query      string
+	UserID     uuid.UUID
+	FeedUrl    sql.NullString
+	Since      sql.NullTime
+	MaxResults int32
+}
+
+type SearchPostsForUserRow struct {
+	ID          uuid.UUID
+	IntID       int64
+	Title       string
+	Url         string
+	PublishedAt sql.NullTime
+	FeedName    string
+	Rank        float32
+	Headline    string
+}
+
+func (q *Queries) SearchPostsForUser(ctx context.Context, arg SearchPostsForUserParams) ([]SearchPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPostsForUser,
+		arg.Query,
+		arg.UserID,
+		arg.FeedUrl,
+		arg.Since,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsForUserRow
+	for rows.Next() {
+		var i SearchPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IntID,
+			&i.Title,
+			&i.Url,
+			&i.PublishedAt,
+			&i.FeedName,
+			&i.Rank,
+			&i.Headline,
 		); err != nil {
 			return nil, err
 		}
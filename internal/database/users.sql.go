@@ -7,31 +7,42 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
-    VALUES ($1, $2, $3, $4)
+-- the first user to register while there is no admin becomes one
+INSERT INTO users (id, created_at, updated_at, name, hashed_password, is_admin)
+    VALUES ($1, $2, $3, $4, $5, NOT EXISTS (
+            SELECT
+                1
+            FROM
+                users
+            WHERE
+                is_admin))
 RETURNING
-    id, created_at, updated_at, name
+    id, created_at, updated_at, name, hashed_password, is_admin, fever_api_key
 `
 
 type CreateUserParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	HashedPassword sql.NullString
 }
 
+// the first user to register while there is no admin becomes one
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
 	row := q.db.QueryRowContext(ctx, createUser,
 		arg.ID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.Name,
+		arg.HashedPassword,
 	)
 	var i User
 	err := row.Scan(
@@ -39,13 +50,29 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.HashedPassword,
+		&i.IsAdmin,
+		&i.FeverApiKey,
 	)
 	return i, err
 }
 
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE name = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, name string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getUser = `-- name: GetUser :one
 SELECT
-    id, created_at, updated_at, name
+    id, created_at, updated_at, name, hashed_password, is_admin, fever_api_key
 FROM
     users
 WHERE
@@ -60,13 +87,40 @@ func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.HashedPassword,
+		&i.IsAdmin,
+		&i.FeverApiKey,
+	)
+	return i, err
+}
+
+const getUserByFeverKey = `-- name: GetUserByFeverKey :one
+SELECT
+    id, created_at, updated_at, name, hashed_password, is_admin, fever_api_key
+FROM
+    users
+WHERE
+    fever_api_key = $1
+`
+
+func (q *Queries) GetUserByFeverKey(ctx context.Context, feverApiKey sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByFeverKey, feverApiKey)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.HashedPassword,
+		&i.IsAdmin,
+		&i.FeverApiKey,
 	)
 	return i, err
 }
 
 const getUsers = `-- name: GetUsers :many
 SELECT
-    id, created_at, updated_at, name
+    id, created_at, updated_at, name, hashed_password, is_admin, fever_api_key
 FROM
     users
 `
@@ -85,6 +139,9 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
+			&i.HashedPassword,
+			&i.IsAdmin,
+			&i.FeverApiKey,
 		); err != nil {
 			return nil, err
 		}
@@ -107,3 +164,69 @@ func (q *Queries) Reset(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, reset)
 	return err
 }
+
+const setUserAdmin = `-- name: SetUserAdmin :execrows
+UPDATE
+    users
+SET
+    is_admin = $2,
+    updated_at = $3
+WHERE
+    name = $1
+`
+
+type SetUserAdminParams struct {
+	Name      string
+	IsAdmin   bool
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetUserAdmin(ctx context.Context, arg SetUserAdminParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserAdmin, arg.Name, arg.IsAdmin, arg.UpdatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserFeverKey = `-- name: SetUserFeverKey :exec
+UPDATE
+    users
+SET
+    fever_api_key = $2,
+    updated_at = $3
+WHERE
+    id = $1
+`
+
+type SetUserFeverKeyParams struct {
+	ID          uuid.UUID
+	FeverApiKey sql.NullString
+	UpdatedAt   time.Time
+}
+
+func (q *Queries) SetUserFeverKey(ctx context.Context, arg SetUserFeverKeyParams) error {
+	_, err := q.db.ExecContext(ctx, setUserFeverKey, arg.ID, arg.FeverApiKey, arg.UpdatedAt)
+	return err
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+UPDATE
+    users
+SET
+    hashed_password = $2,
+    updated_at = $3
+WHERE
+    id = $1
+`
+
+type SetUserPasswordParams struct {
+	ID             uuid.UUID
+	HashedPassword sql.NullString
+	UpdatedAt      time.Time
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.ID, arg.HashedPassword, arg.UpdatedAt)
+	return err
+}
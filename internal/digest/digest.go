@@ -0,0 +1,179 @@
+// Package digest renders unread posts into an email and sends it over
+// SMTP.
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	texttemplate "text/template"
+	"time"
+)
+
+type Post struct {
+	Title   string
+	Url     string
+	Feed    string
+	Author  string
+	Excerpt string
+	Date    time.Time
+}
+
+// Message is a digest ready to send, with the same posts as plain text and
+// HTML.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type feedGroup struct {
+	Name  string
+	Posts []Post
+}
+
+type digestData struct {
+	User   string
+	Period string
+	Count  int
+	Feeds  []feedGroup
+}
+
+const textDigest = `Hi {{.User}}, here are {{.Count}} new posts from your {{.Period}} gator digest.
+{{range .Feeds}}
+== {{.Name}} ==
+{{range .Posts}}
+* {{.Title}}
+  {{.Url}}
+  {{.Date.Format "2006-01-02 15:04"}}{{if .Author}} by {{.Author}}{{end}}
+{{- if .Excerpt}}
+  {{.Excerpt}}{{end}}
+{{end}}{{end}}`
+
+const htmlDigest = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; max-width: 40em;">
+<p>Hi {{.User}}, here are {{.Count}} new posts from your {{.Period}} gator digest.</p>
+{{range .Feeds}}
+<h2 style="font-size: 1.1em; border-bottom: 1px solid #ccc;">{{.Name}}</h2>
+{{range .Posts}}
+<p><a href="{{.Url}}"><strong>{{.Title}}</strong></a><br>
+<small>{{.Date.Format "2006-01-02 15:04"}}{{if .Author}} by {{.Author}}{{end}}</small>
+{{- if .Excerpt}}<br>
+{{.Excerpt}}{{end}}</p>
+{{end}}{{end}}
+</body>
+</html>
+`
+
+var (
+	textTemplate = texttemplate.Must(texttemplate.New("digest").Parse(textDigest))
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("digest").Parse(htmlDigest))
+)
+
+// Render builds the digest of posts for user. Posts are grouped by feed in
+// the order they are given, period is "daily" or "weekly".
+func Render(from, to, user, period string, posts []Post) (Message, error) {
+	data := digestData{User: user, Period: period, Count: len(posts)}
+	for _, p := range posts {
+		if len(data.Feeds) == 0 || data.Feeds[len(data.Feeds)-1].Name != p.Feed {
+			data.Feeds = append(data.Feeds, feedGroup{Name: p.Feed})
+		}
+		g := &data.Feeds[len(data.Feeds)-1]
+		g.Posts = append(g.Posts, p)
+	}
+
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return Message{}, err
+	}
+	if err := htmlTemplate.Execute(&html, data); err != nil {
+		return Message{}, err
+	}
+	return Message{
+		From:    from,
+		To:      to,
+		Subject: fmt.Sprintf("Your %s gator digest: %d new posts", period, len(posts)),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// Bytes returns m as a multipart/alternative email.
+func (m Message) Bytes() ([]byte, error) {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", w.Boundary())
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Server is the SMTP server digests are sent through. Without a username
+// the message is sent unauthenticated, as local mail sinks expect.
+type Server struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+func Send(srv Server, m Message) error {
+	msg, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if srv.Username != "" {
+		auth = smtp.PlainAuth("", srv.Username, srv.Password, srv.Host)
+	}
+	// From and To may carry display names, like "gator <gator@example.com>",
+	// which belong in the headers but not in the SMTP envelope
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address %q: %w", m.From, err)
+	}
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return fmt.Errorf("invalid to address %q: %w", m.To, err)
+	}
+	addr := net.JoinHostPort(srv.Host, strconv.Itoa(srv.Port))
+	return smtp.SendMail(addr, auth, from.Address, []string{to.Address}, msg)
+}
@@ -0,0 +1,147 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// channel is the Postgres notification channel new posts are announced
+// on. It has to match the NotifyNewPost query.
+const channel = "gator_new_posts"
+
+// Post is the event published when gator agg stores a new post. It only
+// carries what fits comfortably in a NOTIFY payload, which Postgres caps
+// at 8000 bytes.
+type Post struct {
+	ID          uuid.UUID  `json:"id"`
+	FeedID      uuid.UUID  `json:"feed_id"`
+	Feed        string     `json:"feed"`
+	Title       string     `json:"title"`
+	Url         string     `json:"url"`
+	Author      string     `json:"author,omitempty"`
+	Categories  []string   `json:"categories"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// maxTitle keeps the payload under the NOTIFY limit for feeds with
+// runaway titles.
+const maxTitle = 1000
+
+func NewPost(p database.Post, feed database.Feed) Post {
+	e := Post{
+		ID:         p.ID,
+		FeedID:     feed.ID,
+		Feed:       feed.Name,
+		Title:      p.Title,
+		Url:        p.Url,
+		Author:     p.Author.String,
+		Categories: p.Categories,
+		CreatedAt:  p.CreatedAt,
+	}
+	if len(e.Title) > maxTitle {
+		e.Title = strings.ToValidUTF8(e.Title[:maxTitle], "")
+	}
+	if e.Categories == nil {
+		e.Categories = []string{}
+	}
+	if p.PublishedAt.Valid {
+		e.PublishedAt = &p.PublishedAt.Time
+	}
+	return e
+}
+
+// Publish announces a new post to every process listening, such as gator
+// serve. Posts published while nobody listens are not kept.
+func Publish(ctx context.Context, db *database.Queries, post Post) error {
+	payload, err := json.Marshal(post)
+	if err != nil {
+		return err
+	}
+	return db.NotifyNewPost(ctx, string(payload))
+}
+
+// Hub listens for published posts and fans them out to subscribers.
+type Hub struct {
+	listener *pq.Listener
+	onError  func(error)
+
+	mu   sync.Mutex
+	subs map[chan Post]struct{}
+}
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before events to it are dropped.
+const subscriberBuffer = 64
+
+// Listen opens a dedicated connection to the database at dbURL and starts
+// delivering published posts to subscribers. Connection problems and
+// malformed events are passed to onError, which must not block; a nil
+// onError logs them.
+func Listen(dbURL string, onError func(error)) (*Hub, error) {
+	if onError == nil {
+		onError = func(err error) { log.Printf("post events: %s", err) }
+	}
+	listener := pq.NewListener(dbURL, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			onError(fmt.Errorf("listener: %w", err))
+		}
+	})
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	h := &Hub{listener: listener, onError: onError, subs: map[chan Post]struct{}{}}
+	go h.run()
+	return h, nil
+}
+
+func (h *Hub) run() {
+	for n := range h.listener.Notify {
+		// a nil notification means the connection was re-established,
+		// events sent while it was down are lost
+		if n == nil {
+			continue
+		}
+		var post Post
+		if err := json.Unmarshal([]byte(n.Extra), &post); err != nil {
+			h.onError(fmt.Errorf("malformed event: %w", err))
+			continue
+		}
+		h.mu.Lock()
+		for sub := range h.subs {
+			select {
+			case sub <- post:
+			default:
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// Subscribe returns a channel receiving every post published from now on
+// and a function to stop receiving them.
+func (h *Hub) Subscribe() (<-chan Post, func()) {
+	sub := make(chan Post, subscriberBuffer)
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub, func() {
+		h.mu.Lock()
+		delete(h.subs, sub)
+		h.mu.Unlock()
+	}
+}
+
+func (h *Hub) Close() error {
+	return h.listener.Close()
+}
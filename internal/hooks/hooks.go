@@ -0,0 +1,109 @@
+// Package hooks runs the shell commands configured to react to new posts.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTimeout applies to hooks that don't set their own.
+const DefaultTimeout = 30 * time.Second
+
+// Post is the JSON a hook command gets on stdin.
+type Post struct {
+	Hook        string     `json:"hook"`
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Url         string     `json:"url"`
+	Feed        string     `json:"feed"`
+	FeedUrl     string     `json:"feed_url"`
+	Folder      string     `json:"folder,omitempty"`
+	Author      string     `json:"author,omitempty"`
+	Categories  []string   `json:"categories"`
+	Description string     `json:"description,omitempty"`
+	Content     string     `json:"content,omitempty"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// env is the subset of the post passed in GATOR_ environment variables,
+// for commands that don't want to parse JSON.
+func (p Post) env() []string {
+	published := ""
+	if p.PublishedAt != nil {
+		published = p.PublishedAt.Format(time.RFC3339)
+	}
+	return []string{
+		"GATOR_HOOK=" + p.Hook,
+		"GATOR_POST_ID=" + p.ID.String(),
+		"GATOR_TITLE=" + p.Title,
+		"GATOR_URL=" + p.Url,
+		"GATOR_FEED=" + p.Feed,
+		"GATOR_FEED_URL=" + p.FeedUrl,
+		"GATOR_FOLDER=" + p.Folder,
+		"GATOR_AUTHOR=" + p.Author,
+		"GATOR_PUBLISHED_AT=" + published,
+	}
+}
+
+// Runner starts hook commands in the background, no more than its
+// concurrency limit at once. Run blocks while the limit is reached, so a
+// burst of posts slows down ingestion instead of piling up processes.
+type Runner struct {
+	slots chan struct{}
+}
+
+func NewRunner(concurrency int) *Runner {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Runner{slots: make(chan struct{}, concurrency)}
+}
+
+// Run starts command with sh for post. The command is killed after
+// timeout, failures are logged with the command's output.
+func (r *Runner) Run(command string, timeout time.Duration, post Post) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	r.slots <- struct{}{}
+	go func() {
+		defer func() { <-r.slots }()
+		out, err := run(command, timeout, post)
+		if err != nil {
+			log.Printf("hook %s failed for %q: %s\n%s", post.Hook, post.Title, err, out)
+		}
+	}()
+}
+
+func run(command string, timeout time.Duration, post Post) ([]byte, error) {
+	payload, err := json.Marshal(post)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), post.env()...)
+	// don't wait forever on children that keep the output open
+	cmd.WaitDelay = time.Second
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return out.Bytes(), fmt.Errorf("timed out after %s", timeout)
+	}
+	return out.Bytes(), err
+}
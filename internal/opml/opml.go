@@ -0,0 +1,147 @@
+package opml
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+	OwnerName   string `xml:"ownerName,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	URL      string    `xml:"url,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Subscription is a feed found in an OPML document. Folder is the path of
+// the outlines the feed was nested in, joined with "/", and empty for
+// feeds at the top level.
+type Subscription struct {
+	Title   string
+	XMLURL  string
+	HTMLURL string
+	Folder  string
+}
+
+func Parse(r io.Reader) (*OPML, error) {
+	var doc OPML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc.XMLName.Local != "opml" {
+		return nil, errors.New("not an OPML document")
+	}
+	return &doc, nil
+}
+
+// Subscriptions flattens the outline tree into the feeds it contains.
+// Outlines without a feed url that contain other outlines are treated as
+// folders, outlines with neither are returned with an empty XMLURL so the
+// caller can report them.
+func (o *OPML) Subscriptions() []Subscription {
+	var subs []Subscription
+	var walk func(outlines []Outline, path []string)
+	walk = func(outlines []Outline, path []string) {
+		for _, out := range outlines {
+			feedURL := out.XMLURL
+			if feedURL == "" && out.Type == "rss" {
+				// some OPML 1.0 exporters use url instead of xmlUrl
+				feedURL = out.URL
+			}
+			if feedURL == "" && len(out.Outlines) > 0 {
+				walk(out.Outlines, append(path, out.name()))
+				continue
+			}
+			subs = append(subs, Subscription{
+				Title:   out.name(),
+				XMLURL:  strings.TrimSpace(feedURL),
+				HTMLURL: strings.TrimSpace(out.HTMLURL),
+				Folder:  strings.Join(path, "/"),
+			})
+		}
+	}
+	walk(o.Body.Outlines, nil)
+	return subs
+}
+
+func (o Outline) name() string {
+	if o.Title != "" {
+		return o.Title
+	}
+	return o.Text
+}
+
+// New builds an OPML 2.0 document from subscriptions, turning each folder
+// path back into nested outlines.
+func New(title string, subs []Subscription) *OPML {
+	doc := &OPML{
+		Version: "2.0",
+		Head:    Head{Title: title, DateCreated: time.Now().UTC().Format(time.RFC1123Z)},
+	}
+
+	for _, sub := range subs {
+		outlines := &doc.Body.Outlines
+		if sub.Folder != "" {
+			for _, name := range strings.Split(sub.Folder, "/") {
+				outlines = &folderOutline(outlines, name).Outlines
+			}
+		}
+		*outlines = append(*outlines, Outline{
+			Text:    sub.Title,
+			Title:   sub.Title,
+			Type:    "rss",
+			XMLURL:  sub.XMLURL,
+			HTMLURL: sub.HTMLURL,
+		})
+	}
+	return doc
+}
+
+// folderOutline returns the folder outline with the given name among
+// outlines, appending a new one if there is none.
+func folderOutline(outlines *[]Outline, name string) *Outline {
+	for i := range *outlines {
+		out := &(*outlines)[i]
+		if out.XMLURL == "" && out.Text == name {
+			return out
+		}
+	}
+	*outlines = append(*outlines, Outline{Text: name, Title: name})
+	return &(*outlines)[len(*outlines)-1]
+}
+
+// Write encodes the document to w with an XML declaration.
+func (o *OPML) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(o); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,208 @@
+package rss
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+)
+
+// Channel describes a feed generated from gator's own posts.
+type Channel struct {
+	Title       string
+	Link        string
+	Description string
+	// ID is the permanent identifier of an Atom feed, Link is used when
+	// it is empty.
+	ID string
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Content string     `xml:"xmlns:content,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	Description string   `xml:"description,omitempty"`
+	Content     *cdata   `xml:"content:encoded,omitempty"`
+	Author      string   `xml:"author,omitempty"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+// WriteRSS writes posts to w as an RSS 2.0 document.
+func WriteRSS(w io.Writer, ch Channel, posts []database.Post) error {
+	doc := rssDocument{
+		Version: "2.0",
+		Content: "http://purl.org/rss/1.0/modules/content/",
+		Channel: rssChannel{
+			Title:         ch.Title,
+			Link:          ch.Link,
+			Description:   ch.Description,
+			LastBuildDate: lastUpdated(posts).Format(time.RFC1123Z),
+			Generator:     "gator",
+		},
+	}
+	for _, p := range posts {
+		item := rssItem{
+			Title:       p.Title,
+			Link:        p.Url,
+			GUID:        rssGUID{IsPermaLink: true, Value: p.Url},
+			Description: p.Description.String,
+			Author:      p.Author.String,
+			Categories:  p.Categories,
+			PubDate:     postTime(p).Format(time.RFC1123Z),
+		}
+		if p.Content.Valid {
+			item.Content = &cdata{Value: p.Content.String}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+	return encode(w, doc)
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Subtitle  string      `xml:"subtitle,omitempty"`
+	Updated   string      `xml:"updated"`
+	Links     []atomLink  `xml:"link"`
+	Author    atomPerson  `xml:"author"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+// WriteAtom writes posts to w as an Atom 1.0 document.
+func WriteAtom(w io.Writer, ch Channel, posts []database.Post) error {
+	id := ch.ID
+	if id == "" {
+		id = ch.Link
+	}
+	feed := atomFeed{
+		ID:        id,
+		Title:     ch.Title,
+		Subtitle:  ch.Description,
+		Updated:   lastUpdated(posts).Format(time.RFC3339),
+		Author:    atomPerson{Name: "gator"},
+		Generator: "gator",
+	}
+	if ch.Link != "" {
+		feed.Links = append(feed.Links, atomLink{Href: ch.Link, Rel: "alternate"})
+	}
+
+	for _, p := range posts {
+		entry := atomEntry{
+			ID:      "urn:uuid:" + p.ID.String(),
+			Title:   p.Title,
+			Updated: p.UpdatedAt.UTC().Format(time.RFC3339),
+			Links:   []atomLink{{Href: p.Url, Rel: "alternate"}},
+		}
+		if p.PublishedAt.Valid {
+			entry.Published = p.PublishedAt.Time.UTC().Format(time.RFC3339)
+		}
+		if p.Author.Valid {
+			entry.Author = &atomPerson{Name: p.Author.String}
+		}
+		for _, c := range p.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: c})
+		}
+		if p.Description.Valid {
+			entry.Summary = &atomText{Type: "html", Value: p.Description.String}
+		}
+		if p.Content.Valid {
+			entry.Content = &atomText{Type: "html", Value: p.Content.String}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return encode(w, feed)
+}
+
+func encode(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// postTime is when a post was published, or when gator stored it if the
+// feed did not say.
+func postTime(p database.Post) time.Time {
+	if p.PublishedAt.Valid {
+		return p.PublishedAt.Time
+	}
+	return p.CreatedAt
+}
+
+func lastUpdated(posts []database.Post) time.Time {
+	var latest time.Time
+	for _, p := range posts {
+		if p.UpdatedAt.After(latest) {
+			latest = p.UpdatedAt
+		}
+	}
+	if latest.IsZero() {
+		return time.Now().UTC()
+	}
+	return latest.UTC()
+}
@@ -18,10 +18,14 @@ type RSSFeed struct {
 }
 
 type RSSItem struct {
-	Title       string  `xml:"title"`
-	Link        string  `xml:"link"`
-	Description *string `xml:"description"`
-	PubDate     *string `xml:"pubDate"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description *string  `xml:"description"`
+	Content     *string  `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Author      *string  `xml:"author"`
+	Creator     *string  `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string `xml:"category"`
+	PubDate     *string  `xml:"pubDate"`
 }
 
 func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
@@ -53,7 +57,9 @@ func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
 	for i := range feed.Channel.Item {
 		feed.Channel.Item[i].Title = html.UnescapeString(feed.Channel.Item[i].Title)
-		*feed.Channel.Item[i].Description = html.UnescapeString(*feed.Channel.Item[i].Description)
+		if feed.Channel.Item[i].Description != nil {
+			*feed.Channel.Item[i].Description = html.UnescapeString(*feed.Channel.Item[i].Description)
+		}
 
 	}
 
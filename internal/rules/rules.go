@@ -0,0 +1,89 @@
+// Package rules matches posts against users' filter rules.
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/brinwiththevlin/aggregator/internal/content"
+)
+
+// Fields a rule can look at, "any" tries all of them.
+var Fields = []string{"any", "title", "description", "author", "category"}
+
+// Actions a matching rule can take.
+var Actions = []string{"hide", "read", "star", "tag"}
+
+// Post is the part of a post rules are matched against.
+type Post struct {
+	Title       string
+	Description string
+	Author      string
+	Categories  []string
+}
+
+// Matcher is a compiled rule pattern. Keywords match case-insensitively
+// anywhere in the title, description text and author, and whole
+// categories. Regexes use Go syntax and are case-sensitive unless they
+// start with (?i).
+type Matcher struct {
+	field   string
+	keyword string
+	re      *regexp.Regexp
+}
+
+// Compile checks a rule's field and pattern and prepares it for matching.
+func Compile(field string, isRegex bool, pattern string) (Matcher, error) {
+	if !slices.Contains(Fields, field) {
+		return Matcher{}, fmt.Errorf("unknown field %s, use one of %s", field, strings.Join(Fields, ", "))
+	}
+	if pattern == "" {
+		return Matcher{}, fmt.Errorf("empty pattern")
+	}
+	m := Matcher{field: field}
+	if !isRegex {
+		m.keyword = strings.ToLower(pattern)
+		return m, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Matcher{}, fmt.Errorf("invalid regex: %w", err)
+	}
+	m.re = re
+	return m, nil
+}
+
+// Match reports whether the rule's field of p, or any of them for "any",
+// matches the pattern.
+func (m Matcher) Match(p Post) bool {
+	switch m.field {
+	case "title":
+		return m.matchText(p.Title)
+	case "description":
+		return m.matchText(content.Text(p.Description))
+	case "author":
+		return m.matchText(p.Author)
+	case "category":
+		return m.matchCategories(p.Categories)
+	}
+	return m.matchText(p.Title) || m.matchText(content.Text(p.Description)) ||
+		m.matchText(p.Author) || m.matchCategories(p.Categories)
+}
+
+func (m Matcher) matchText(s string) bool {
+	if m.re != nil {
+		return m.re.MatchString(s)
+	}
+	return strings.Contains(strings.ToLower(s), m.keyword)
+}
+
+func (m Matcher) matchCategories(categories []string) bool {
+	for _, c := range categories {
+		if m.re != nil && m.re.MatchString(c) || m.re == nil && strings.EqualFold(c, m.keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package rules
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	post := Post{
+		Title:       "Weekly Roundup: Go 1.24",
+		Description: `<p>Fixes for <a href="https://example.com/cve">CVE-2025-1234</a></p>`,
+		Author:      "Jane Doe",
+		Categories:  []string{"Security", "golang"},
+	}
+	tests := []struct {
+		name    string
+		field   string
+		isRegex bool
+		pattern string
+		want    bool
+	}{
+		{"keyword in title", "title", false, "roundup", true},
+		{"keyword ignores case", "title", false, "WEEKLY", true},
+		{"keyword missing from title", "title", false, "cve", false},
+		{"keyword in description text", "description", false, "cve-2025", true},
+		{"description markup is not text", "description", false, "href", false},
+		{"keyword in author", "author", false, "doe", true},
+		{"category matches whole", "category", false, "security", true},
+		{"category doesn't match part", "category", false, "secur", false},
+		{"any looks at title", "any", false, "roundup", true},
+		{"any looks at description", "any", false, "fixes", true},
+		{"any looks at author", "any", false, "jane", true},
+		{"any looks at categories", "any", false, "golang", true},
+		{"any without a match", "any", false, "rust", false},
+		{"regex in title", "title", true, `^Weekly \w+`, true},
+		{"regex is case-sensitive", "title", true, `^weekly`, false},
+		{"regex case flag", "title", true, `(?i)^weekly`, true},
+		{"regex on categories", "category", true, `^go`, true},
+		{"regex in description", "description", true, `CVE-\d{4}-\d+`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Compile(tt.field, tt.isRegex, tt.pattern)
+			if err != nil {
+				t.Fatalf("Compile(%q, %v, %q): %v", tt.field, tt.isRegex, tt.pattern, err)
+			}
+			if got := m.Match(post); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		isRegex bool
+		pattern string
+	}{
+		{"unknown field", "body", false, "x"},
+		{"empty pattern", "any", false, ""},
+		{"invalid regex", "any", true, "("},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(tt.field, tt.isRegex, tt.pattern); err == nil {
+				t.Errorf("Compile(%q, %v, %q) succeeded, want an error", tt.field, tt.isRegex, tt.pattern)
+			}
+		})
+	}
+}
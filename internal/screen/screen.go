@@ -0,0 +1,299 @@
+// Package screen draws full-screen terminal interfaces. It puts the
+// terminal in raw mode on the alternate screen, decodes key presses and
+// redraws only the lines that changed between frames.
+package screen
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Style is a set of text attributes.
+type Style uint8
+
+const (
+	Bold Style = 1 << iota
+	Dim
+	Underline
+	Reverse
+)
+
+type cell struct {
+	r     rune
+	style Style
+}
+
+// Screen is a terminal taken over by the program until Close.
+type Screen struct {
+	in    *os.File
+	out   *bufio.Writer
+	state *term.State
+	keys  chan string
+
+	width, height int
+	// back is the frame being drawn, front what the terminal shows
+	back, front [][]cell
+}
+
+// Open takes over the terminal on stdin and stdout.
+func Open() (*Screen, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil, errors.New("not running in a terminal")
+	}
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	s := &Screen{in: os.Stdin, out: bufio.NewWriterSize(os.Stdout, 32*1024), state: state, keys: make(chan string, 16)}
+	// alternate screen, hidden cursor
+	s.out.WriteString("\x1b[?1049h\x1b[?25l")
+	s.Resize()
+	go s.readKeys()
+	return s, nil
+}
+
+// Close gives the terminal back the way Open found it.
+func (s *Screen) Close() error {
+	s.out.WriteString("\x1b[0m\x1b[?25h\x1b[?1049l")
+	s.out.Flush()
+	return term.Restore(int(s.in.Fd()), s.state)
+}
+
+// Keys delivers key presses by name: the character typed, like "j" or
+// "G", or one of "up", "down", "left", "right", "enter", "tab",
+// "backtab", "esc", "backspace", "delete", "home", "end", "pgup",
+// "pgdown" and "ctrl+" followed by a letter.
+func (s *Screen) Keys() <-chan string {
+	return s.keys
+}
+
+// Size returns the screen's width and height in cells.
+func (s *Screen) Size() (int, int) {
+	return s.width, s.height
+}
+
+// Resize picks up a change in the terminal's size and reports whether
+// there was one. The next frame is then drawn from scratch.
+func (s *Screen) Resize() bool {
+	w, h, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || w <= 0 || h <= 0 {
+		w, h = 80, 24
+	}
+	if w == s.width && h == s.height {
+		return false
+	}
+	s.width, s.height = w, h
+	s.back = grid(w, h)
+	s.front = grid(w, h)
+	for _, line := range s.front {
+		// never matches a drawn cell, so every line is redrawn
+		line[0].r = -1
+	}
+	s.out.WriteString("\x1b[2J")
+	return true
+}
+
+func grid(w, h int) [][]cell {
+	g := make([][]cell, h)
+	for y := range g {
+		g[y] = make([]cell, w)
+	}
+	return g
+}
+
+// Clear starts a new frame.
+func (s *Screen) Clear() {
+	for _, line := range s.back {
+		for x := range line {
+			line[x] = cell{r: ' '}
+		}
+	}
+}
+
+// Text draws str at column x of row y, cut off after width cells, and
+// returns the column after it. The rest of the width is left alone.
+func (s *Screen) Text(x, y, width int, str string, style Style) int {
+	if y < 0 || y >= s.height {
+		return x
+	}
+	end := min(x+width, s.width)
+	line := s.back[y]
+	for _, r := range str {
+		if r < ' ' || r == 0x7f {
+			r = ' '
+		}
+		w := RuneWidth(r)
+		if w == 0 {
+			continue
+		}
+		if x+w > end {
+			break
+		}
+		line[x] = cell{r: r, style: style}
+		if w == 2 {
+			// the right half of a wide character is drawn with it
+			line[x+1] = cell{r: 0, style: style}
+		}
+		x += w
+	}
+	return x
+}
+
+// Fill paints width cells of row y from column x with style, for bars
+// and highlighted lines.
+func (s *Screen) Fill(x, y, width int, r rune, style Style) {
+	if y < 0 || y >= s.height {
+		return
+	}
+	for end := min(x+width, s.width); x < end; x++ {
+		s.back[y][x] = cell{r: r, style: style}
+	}
+}
+
+// Show puts the frame on the terminal.
+func (s *Screen) Show() error {
+	for y := range s.back {
+		if lineEqual(s.back[y], s.front[y]) {
+			continue
+		}
+		s.out.WriteString("\x1b[" + strconv.Itoa(y+1) + ";1H")
+		style := Style(0)
+		s.out.WriteString("\x1b[0m")
+		for _, c := range s.back[y] {
+			if c.r == 0 {
+				continue
+			}
+			if c.style != style {
+				s.out.WriteString(sgr(c.style))
+				style = c.style
+			}
+			s.out.WriteRune(c.r)
+		}
+		s.out.WriteString("\x1b[0m")
+		copy(s.front[y], s.back[y])
+	}
+	return s.out.Flush()
+}
+
+func lineEqual(a, b []cell) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sgr(style Style) string {
+	codes := []string{"0"}
+	if style&Bold != 0 {
+		codes = append(codes, "1")
+	}
+	if style&Dim != 0 {
+		codes = append(codes, "2")
+	}
+	if style&Underline != 0 {
+		codes = append(codes, "4")
+	}
+	if style&Reverse != 0 {
+		codes = append(codes, "7")
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// Width is how many cells str takes up on the screen.
+func Width(str string) int {
+	n := 0
+	for _, r := range str {
+		n += RuneWidth(r)
+	}
+	return n
+}
+
+// RuneWidth is how many cells r takes up: 0 for combining marks, 2 for
+// East Asian wide characters and emoji, 1 for the rest.
+func RuneWidth(r rune) int {
+	switch {
+	case unicode.Is(unicode.Mn, r) || r == 0x200b:
+		return 0
+	case r >= 0x1100 && r <= 0x115f, r >= 0x2e80 && r <= 0xa4cf && r != 0x303f,
+		r >= 0xac00 && r <= 0xd7a3, r >= 0xf900 && r <= 0xfaff, r >= 0xfe30 && r <= 0xfe4f,
+		r >= 0xff00 && r <= 0xff60, r >= 0xffe0 && r <= 0xffe6, r >= 0x1f300 && r <= 0x1f64f,
+		r >= 0x1f900 && r <= 0x1f9ff, r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
+
+// readKeys decodes stdin into key names until it fails. The goroutine
+// can't be stopped while blocked on a read, which is fine for a program
+// that exits after Close.
+func (s *Screen) readKeys() {
+	buf := make([]byte, 256)
+	for {
+		n, err := s.in.Read(buf)
+		if err != nil {
+			close(s.keys)
+			return
+		}
+		for b := buf[:n]; len(b) > 0; {
+			key, size := decodeKey(b)
+			b = b[size:]
+			if key != "" {
+				s.keys <- key
+			}
+		}
+	}
+}
+
+var escapes = map[string]string{
+	"[A": "up", "[B": "down", "[C": "right", "[D": "left",
+	"OA": "up", "OB": "down", "OC": "right", "OD": "left",
+	"[H": "home", "[F": "end", "OH": "home", "OF": "end",
+	"[1~": "home", "[7~": "home", "[4~": "end", "[8~": "end",
+	"[3~": "delete", "[5~": "pgup", "[6~": "pgdown", "[Z": "backtab",
+}
+
+// decodeKey names the key at the start of b and returns how many bytes
+// it took. Unknown escape sequences are consumed and named "".
+func decodeKey(b []byte) (string, int) {
+	switch c := b[0]; {
+	case c == 0x1b:
+		if len(b) == 1 {
+			return "esc", 1
+		}
+		if b[1] != '[' && b[1] != 'O' {
+			// alt+key, treat as the key
+			return decodeKey(b[1:])
+		}
+		// a sequence runs to its final byte in @ to ~
+		end := 2
+		for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+			end++
+		}
+		if end == len(b) {
+			return "", len(b)
+		}
+		return escapes[string(b[1:end+1])], end + 1
+	case c == '\r' || c == '\n':
+		return "enter", 1
+	case c == '\t':
+		return "tab", 1
+	case c == 0x7f || c == 0x08:
+		return "backspace", 1
+	case c < 0x20:
+		return "ctrl+" + string(rune('a'+c-1)), 1
+	}
+	r, size := utf8.DecodeRune(b)
+	if r == utf8.RuneError {
+		return "", size
+	}
+	return string(r), size
+}
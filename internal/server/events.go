@@ -0,0 +1,121 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/events"
+	"github.com/google/uuid"
+)
+
+// keepaliveInterval is how often an idle event stream gets a comment so
+// proxies don't close it.
+const keepaliveInterval = 30 * time.Second
+
+// handlerEvents streams posts as gator agg stores them, as server-sent
+// events. Without filters every new post is sent. feed limits the stream
+// to one feed by url, user to the feeds a user follows and folder to one
+// of their folders. Only admins may watch another user's feeds.
+func (s *Server) handlerEvents(w http.ResponseWriter, r *http.Request, user database.User) {
+	if s.hub == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "post events are not available")
+		return
+	}
+	q := r.URL.Query()
+
+	var feedID uuid.NullUUID
+	if url := q.Get("feed"); url != "" {
+		feed, err := s.db.GetFeedByUrl(r.Context(), url)
+		if err != nil {
+			respondWithDBError(w, err)
+			return
+		}
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	// feeds maps the followed feeds the stream is limited to onto the
+	// name the user gave them, nil means no limit
+	var feeds map[uuid.UUID]string
+	if q.Get("user") != "" || q.Get("folder") != "" {
+		subject := user
+		if name := q.Get("user"); name != "" && name != user.Name {
+			if !user.IsAdmin {
+				respondWithError(w, http.StatusForbidden, "only admins can watch other users' feeds")
+				return
+			}
+			var err error
+			subject, err = s.db.GetUser(r.Context(), name)
+			if err != nil {
+				respondWithDBError(w, err)
+				return
+			}
+		}
+		follows, err := s.db.GetFeedFollowForUser(r.Context(), subject.ID)
+		if err != nil {
+			respondWithDBError(w, err)
+			return
+		}
+		folder := q.Get("folder")
+		feeds = map[uuid.UUID]string{}
+		for _, f := range follows {
+			if f.Hidden || (folder != "" && f.FolderName.String != folder) {
+				continue
+			}
+			feeds[f.FeedID] = f.DisplayName.String
+		}
+	}
+
+	// subscribe before answering so nothing published in between is missed
+	posts, unsubscribe := s.hub.Subscribe()
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(keepaliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case post := <-posts:
+			if feedID.Valid && post.FeedID != feedID.UUID {
+				continue
+			}
+			if feeds != nil {
+				name, ok := feeds[post.FeedID]
+				if !ok {
+					continue
+				}
+				if name != "" {
+					post.Feed = name
+				}
+			}
+			if err := writeEvent(w, post); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, post events.Post) error {
+	data, err := json.Marshal(post)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: post\nid: %s\ndata: %s\n\n", post.ID, data)
+	return err
+}
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Server) handlerFeedsList(w http.ResponseWriter, r *http.Request) {
+	feeds, err := s.db.GetFeeds(r.Context())
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	resp := []Feed{}
+	for _, f := range feeds {
+		resp = append(resp, Feed{
+			ID:            f.ID,
+			Name:          f.Name,
+			Url:           f.Url,
+			SiteUrl:       f.SiteUrl.String,
+			CreatedBy:     f.UserName,
+			LastFetchedAt: nullTime(f.LastFetchedAt),
+			CreatedAt:     f.CreatedAt,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// handlerFeedsCreate adds a feed and follows it for the user, like
+// gator addfeed.
+func (s *Server) handlerFeedsCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	var params struct {
+		Name string `json:"name"`
+		Url  string `json:"url"`
+	}
+	if err := decodeJSON(r, &params); err != nil || params.Name == "" || params.Url == "" {
+		respondWithError(w, http.StatusBadRequest, "body must be {\"name\": string, \"url\": string}")
+		return
+	}
+
+	feed, err := s.db.CreateFeed(r.Context(), database.CreateFeedParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: params.Name, Url: params.Url, UserID: user.ID})
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	_, err = s.db.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, FeedID: feed.ID, Notify: true})
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, Feed{
+		ID:        feed.ID,
+		Name:      feed.Name,
+		Url:       feed.Url,
+		CreatedBy: user.Name,
+		CreatedAt: feed.CreatedAt,
+	})
+}
+
+// feedForEdit loads the feed named in the path and checks that user may
+// change it, writing the error response when not.
+func (s *Server) feedForEdit(w http.ResponseWriter, r *http.Request, user database.User) (database.Feed, bool) {
+	feedID, err := uuid.Parse(r.PathValue("feedID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid feed id")
+		return database.Feed{}, false
+	}
+	feed, err := s.db.GetFeed(r.Context(), feedID)
+	if err != nil {
+		respondWithDBError(w, err)
+		return database.Feed{}, false
+	}
+	if !user.IsAdmin && feed.UserID != user.ID {
+		respondWithError(w, http.StatusForbidden, "only the user who added the feed or an admin can change it")
+		return database.Feed{}, false
+	}
+	return feed, true
+}
+
+func (s *Server) handlerFeedsDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := s.feedForEdit(w, r, user)
+	if !ok {
+		return
+	}
+	err := s.db.DeleteFeed(r.Context(), feed.ID)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerFeedsRetention overrides a feed's retention limits like gator
+// retention, null falls back to the server's defaults.
+func (s *Server) handlerFeedsRetention(w http.ResponseWriter, r *http.Request, user database.User) {
+	var params struct {
+		MaxAgeDays *int32 `json:"max_age_days"`
+		MaxPosts   *int32 `json:"max_posts"`
+	}
+	err := decodeJSON(r, &params)
+	if err != nil || (params.MaxAgeDays != nil && *params.MaxAgeDays < 0) || (params.MaxPosts != nil && *params.MaxPosts < 0) {
+		respondWithError(w, http.StatusBadRequest, "body must be {\"max_age_days\": int|null, \"max_posts\": int|null}, limits are non-negative")
+		return
+	}
+	feed, ok := s.feedForEdit(w, r, user)
+	if !ok {
+		return
+	}
+
+	args := database.SetFeedRetentionParams{ID: feed.ID, UpdatedAt: time.Now()}
+	if params.MaxAgeDays != nil {
+		args.RetentionMaxAgeDays = sql.NullInt32{Int32: *params.MaxAgeDays, Valid: true}
+	}
+	if params.MaxPosts != nil {
+		args.RetentionMaxPosts = sql.NullInt32{Int32: *params.MaxPosts, Valid: true}
+	}
+	err = s.db.SetFeedRetention(r.Context(), args)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlerFollowsList(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := s.db.GetFeedFollowForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	resp := []Follow{}
+	for _, f := range follows {
+		resp = append(resp, Follow{
+			FeedID:      f.FeedID,
+			FeedName:    f.FeedName.String,
+			FeedUrl:     f.FeedUrl.String,
+			DisplayName: f.DisplayName.String,
+			Folder:      f.FolderName.String,
+			Notify:      f.Notify,
+			Hidden:      f.Hidden,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handlerFollowsCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	var params struct {
+		Url         string `json:"url"`
+		DisplayName string `json:"display_name"`
+		Notify      *bool  `json:"notify"`
+		Hidden      bool   `json:"hidden"`
+	}
+	if err := decodeJSON(r, &params); err != nil || params.Url == "" {
+		respondWithError(w, http.StatusBadRequest, "body must be {\"url\": string, \"display_name\"?: string, \"notify\"?: bool, \"hidden\"?: bool}")
+		return
+	}
+
+	feed, err := s.db.GetFeedByUrl(r.Context(), params.Url)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	args := database.CreateFeedFollowParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, FeedID: feed.ID, Notify: true, Hidden: params.Hidden}
+	if params.Notify != nil {
+		args.Notify = *params.Notify
+	}
+	if params.DisplayName != "" {
+		args.DisplayName = sql.NullString{String: params.DisplayName, Valid: true}
+	}
+	follow, err := s.db.CreateFeedFollow(r.Context(), args)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, Follow{
+		FeedID:      feed.ID,
+		FeedName:    follow.FeedName,
+		FeedUrl:     feed.Url,
+		DisplayName: follow.DisplayName.String,
+		Notify:      follow.Notify,
+		Hidden:      follow.Hidden,
+	})
+}
+
+func (s *Server) handlerFollowsDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(r.PathValue("feedID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid feed id")
+		return
+	}
+	_, err = s.db.GetFeedFollow(r.Context(), database.GetFeedFollowParams{UserID: user.ID, FeedID: feedID})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "not following that feed")
+		return
+	}
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	err = s.db.DeleteFeedFollow(r.Context(), database.DeleteFeedFollowParams{UserID: user.ID, FeedID: feedID})
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,345 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/content"
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+// feverPageSize is how many items the Fever API returns per request, the
+// protocol fixes it at 50.
+const feverPageSize = 50
+
+func (s *Server) feverRoutes() {
+	s.mux.HandleFunc("/fever/", s.handlerFever)
+}
+
+type feverGroup struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type feverFeedsGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feverFeed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	Url               string `json:"url"`
+	SiteUrl           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type feverItem struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	Html          string `json:"html"`
+	Url           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// handlerFever implements the Fever API. Everything goes through one
+// endpoint: the api_key field authenticates, query parameters pick what
+// to return and mark, as and id change read and saved state.
+func (s *Server) handlerFever(w http.ResponseWriter, r *http.Request) {
+	resp := map[string]any{"api_version": 3, "auth": 0}
+	if _, ok := r.URL.Query()["api"]; !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	key := strings.ToLower(r.FormValue("api_key"))
+	user, err := s.db.GetUserByFeverKey(r.Context(), sql.NullString{String: key, Valid: key != ""})
+	if errors.Is(err, sql.ErrNoRows) || key == "" {
+		respondWithJSON(w, http.StatusOK, resp)
+		return
+	}
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	resp["auth"] = 1
+	resp["last_refreshed_on_time"] = time.Now().Unix()
+
+	if err := s.feverRespond(r, user, resp); err != nil {
+		if errors.Is(err, errFeverRequest) {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondWithDBError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+var errFeverRequest = errors.New("invalid fever request")
+
+func (s *Server) feverRespond(r *http.Request, user database.User, resp map[string]any) error {
+	ctx := r.Context()
+	has := func(name string) bool {
+		_, ok := r.Form[name]
+		return ok
+	}
+
+	if r.FormValue("mark") != "" {
+		if err := s.feverMark(r, user); err != nil {
+			return err
+		}
+	}
+
+	var subs []database.GetUnreadCountsForUserRow
+	if has("groups") || has("feeds") {
+		var err error
+		subs, err = s.db.GetUnreadCountsForUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		resp["feeds_groups"] = feverFeedsGroups(subs)
+	}
+	if has("groups") {
+		groups := []feverGroup{}
+		seen := map[int64]bool{}
+		for _, sub := range subs {
+			if sub.FolderIntID.Valid && !seen[sub.FolderIntID.Int64] {
+				seen[sub.FolderIntID.Int64] = true
+				groups = append(groups, feverGroup{ID: sub.FolderIntID.Int64, Title: sub.FolderName.String})
+			}
+		}
+		resp["groups"] = groups
+	}
+	if has("feeds") {
+		feeds := []feverFeed{}
+		for _, sub := range subs {
+			feeds = append(feeds, feverFeed{
+				ID:                sub.FeedIntID,
+				Title:             sub.FeedName,
+				Url:               sub.FeedUrl,
+				SiteUrl:           sub.SiteUrl.String,
+				LastUpdatedOnTime: time.Now().Unix(),
+			})
+		}
+		resp["feeds"] = feeds
+	}
+	if has("favicons") {
+		resp["favicons"] = []any{}
+	}
+	if has("links") {
+		resp["links"] = []any{}
+	}
+
+	if has("items") {
+		items, total, err := s.feverItems(r, user)
+		if err != nil {
+			return err
+		}
+		resp["items"] = items
+		resp["total_items"] = total
+	}
+	if has("unread_item_ids") || r.FormValue("mark") != "" {
+		ids, err := s.db.GetItemIDsForUser(ctx, database.GetItemIDsForUserParams{UserID: user.ID, UnreadOnly: true, MaxResults: math.MaxInt32})
+		if err != nil {
+			return err
+		}
+		resp["unread_item_ids"] = joinIDs(ids)
+	}
+	if has("saved_item_ids") || r.FormValue("mark") != "" {
+		ids, err := s.db.GetItemIDsForUser(ctx, database.GetItemIDsForUserParams{UserID: user.ID, StarredOnly: true, MaxResults: math.MaxInt32})
+		if err != nil {
+			return err
+		}
+		resp["saved_item_ids"] = joinIDs(ids)
+	}
+	return nil
+}
+
+func (s *Server) feverItems(r *http.Request, user database.User) ([]feverItem, int64, error) {
+	ctx := r.Context()
+	total, err := s.db.CountItemsForUser(ctx, user.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var ids []int64
+	switch {
+	case r.FormValue("with_ids") != "":
+		for _, v := range strings.Split(r.FormValue("with_ids"), ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, 0, errFeverRequest
+			}
+			ids = append(ids, id)
+		}
+		if len(ids) > feverPageSize {
+			ids = ids[:feverPageSize]
+		}
+	case r.FormValue("max_id") != "":
+		maxID, err := strconv.ParseInt(r.FormValue("max_id"), 10, 64)
+		if err != nil {
+			return nil, 0, errFeverRequest
+		}
+		ids, err = s.db.GetItemIDsForUser(ctx, database.GetItemIDsForUserParams{UserID: user.ID, MaxID: sql.NullInt64{Int64: maxID, Valid: true}, MaxResults: feverPageSize})
+		if err != nil {
+			return nil, 0, err
+		}
+	default:
+		sinceID, err := strconv.ParseInt(r.FormValue("since_id"), 10, 64)
+		if err != nil {
+			sinceID = 0
+		}
+		ids, err = s.db.GetItemIDsForUser(ctx, database.GetItemIDsForUserParams{UserID: user.ID, MinID: sql.NullInt64{Int64: sinceID, Valid: true}, OldestFirst: true, MaxResults: feverPageSize})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	rows, err := s.db.GetItemsForUser(ctx, database.GetItemsForUserParams{UserID: user.ID, Ids: ids})
+	if err != nil {
+		return nil, 0, err
+	}
+	items := []feverItem{}
+	for _, row := range rows {
+		body := row.Content.String
+		if !row.Content.Valid {
+			body = row.Description.String
+		}
+		item := feverItem{
+			ID:            row.IntID,
+			FeedID:        row.FeedIntID,
+			Title:         row.Title,
+			Author:        row.Author.String,
+			Html:          content.Sanitize(body),
+			Url:           row.Url,
+			CreatedOnTime: row.CreatedAt.Unix(),
+		}
+		if row.PublishedAt.Valid {
+			item.CreatedOnTime = row.PublishedAt.Time.Unix()
+		}
+		if row.IsRead {
+			item.IsRead = 1
+		}
+		if row.IsStarred {
+			item.IsSaved = 1
+		}
+		items = append(items, item)
+	}
+	// items come back newest first, since_id pages are read oldest first
+	if r.FormValue("max_id") == "" {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	return items, total, nil
+}
+
+// feverMark handles mark=item|feed|group with as=read|unread|saved|unsaved.
+// Feeds and groups can only be marked read, up to the before timestamp.
+func (s *Server) feverMark(r *http.Request, user database.User) error {
+	ctx := r.Context()
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		return errFeverRequest
+	}
+
+	if r.FormValue("mark") == "item" {
+		ids := []int64{id}
+		switch r.FormValue("as") {
+		case "read":
+			return s.db.MarkItemsRead(ctx, database.MarkItemsReadParams{UserID: user.ID, Ids: ids})
+		case "unread":
+			return s.db.MarkItemsUnread(ctx, database.MarkItemsUnreadParams{UserID: user.ID, Ids: ids})
+		case "saved":
+			return s.db.StarItems(ctx, database.StarItemsParams{UserID: user.ID, Ids: ids})
+		case "unsaved":
+			return s.db.UnstarItems(ctx, database.UnstarItemsParams{UserID: user.ID, Ids: ids})
+		}
+		return errFeverRequest
+	}
+
+	if r.FormValue("as") != "read" {
+		return errFeverRequest
+	}
+	args := database.MarkFeedItemsReadParams{UserID: user.ID, Before: time.Now()}
+	if before, err := strconv.ParseInt(r.FormValue("before"), 10, 64); err == nil && before > 0 {
+		args.Before = time.Unix(before, 0)
+	}
+
+	subs, err := s.db.GetUnreadCountsForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	switch r.FormValue("mark") {
+	case "feed":
+		for _, sub := range subs {
+			if sub.FeedIntID == id {
+				args.FeedID = uuid.NullUUID{UUID: sub.FeedID, Valid: true}
+			}
+		}
+		if !args.FeedID.Valid {
+			return errFeverRequest
+		}
+	case "group":
+		// group 0 is everything, -1 the sparks gator doesn't have
+		if id < 0 {
+			return nil
+		}
+		if id > 0 {
+			for _, sub := range subs {
+				if sub.FolderIntID.Int64 == id {
+					args.Folder = sub.FolderName
+				}
+			}
+			if !args.Folder.Valid {
+				return errFeverRequest
+			}
+		}
+	default:
+		return errFeverRequest
+	}
+	_, err = s.db.MarkFeedItemsRead(ctx, args)
+	return err
+}
+
+func feverFeedsGroups(subs []database.GetUnreadCountsForUserRow) []feverFeedsGroup {
+	groups := []feverFeedsGroup{}
+	index := map[int64]int{}
+	for _, sub := range subs {
+		if !sub.FolderIntID.Valid {
+			continue
+		}
+		i, ok := index[sub.FolderIntID.Int64]
+		if !ok {
+			i = len(groups)
+			index[sub.FolderIntID.Int64] = i
+			groups = append(groups, feverFeedsGroup{GroupID: sub.FolderIntID.Int64})
+		}
+		if groups[i].FeedIDs != "" {
+			groups[i].FeedIDs += ","
+		}
+		groups[i].FeedIDs += strconv.FormatInt(sub.FeedIntID, 10)
+	}
+	return groups
+}
+
+func joinIDs(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
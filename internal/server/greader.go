@@ -0,0 +1,536 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/content"
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+// The Google Reader API as spoken by Reeder, NetNewsWire, FeedMe and
+// friends. Streams are named like the original service: the timeline is
+// user/-/state/com.google/reading-list, folders are user/-/label/<name>
+// and feeds are feed/<id>.
+const (
+	streamReadingList = "user/-/state/com.google/reading-list"
+	streamRead        = "user/-/state/com.google/read"
+	streamStarred     = "user/-/state/com.google/starred"
+	streamKeptUnread  = "user/-/state/com.google/kept-unread"
+	labelPrefix       = "user/-/label/"
+	feedPrefix        = "feed/"
+	longItemPrefix    = "tag:google.com,2005:reader/item/"
+)
+
+const (
+	greaderDefaultItems = 20
+	greaderMaxItems     = 1000
+	greaderMaxIDs       = 10000
+)
+
+func (s *Server) greaderRoutes() {
+	s.mux.HandleFunc("POST /accounts/ClientLogin", s.handlerGReaderLogin)
+	s.mux.HandleFunc("GET /reader/api/0/token", s.middlewareGoogleLogin(s.handlerGReaderToken))
+	s.mux.HandleFunc("GET /reader/api/0/user-info", s.middlewareGoogleLogin(s.handlerGReaderUserInfo))
+	s.mux.HandleFunc("GET /reader/api/0/subscription/list", s.middlewareGoogleLogin(s.handlerGReaderSubscriptions))
+	s.mux.HandleFunc("GET /reader/api/0/tag/list", s.middlewareGoogleLogin(s.handlerGReaderTags))
+	s.mux.HandleFunc("GET /reader/api/0/unread-count", s.middlewareGoogleLogin(s.handlerGReaderUnreadCount))
+	s.mux.HandleFunc("GET /reader/api/0/stream/items/ids", s.middlewareGoogleLogin(s.handlerGReaderItemIDs))
+	s.mux.HandleFunc("/reader/api/0/stream/items/contents", s.middlewareGoogleLogin(s.handlerGReaderItemContents))
+	s.mux.HandleFunc("GET /reader/api/0/stream/contents", s.middlewareGoogleLogin(s.handlerGReaderStreamContents))
+	s.mux.HandleFunc("GET /reader/api/0/stream/contents/{stream...}", s.middlewareGoogleLogin(s.handlerGReaderStreamContents))
+	s.mux.HandleFunc("POST /reader/api/0/edit-tag", s.middlewareGoogleLogin(s.handlerGReaderEditTag))
+	s.mux.HandleFunc("POST /reader/api/0/mark-all-as-read", s.middlewareGoogleLogin(s.handlerGReaderMarkAllRead))
+}
+
+// middlewareGoogleLogin is middlewareAuth for Google Reader clients, which
+// send the token from ClientLogin as "Authorization: GoogleLogin auth=...".
+func (s *Server) middlewareGoogleLogin(handler func(http.ResponseWriter, *http.Request, database.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "GoogleLogin auth=")
+		if !ok || token == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		user, err := s.userForToken(r.Context(), token)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			s.webError(w, err)
+			return
+		}
+		handler(w, r, user)
+	}
+}
+
+func (s *Server) handlerGReaderLogin(w http.ResponseWriter, r *http.Request) {
+	creds := credentials{Name: r.FormValue("Email"), Password: r.FormValue("Passwd")}
+	token, err := s.login(r.Context(), creds, "greader login")
+	if errors.Is(err, errBadCredentials) {
+		http.Error(w, "Error=BadAuthentication", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "SID=%s\nLSID=%s\nAuth=%s\n", token, token, token)
+}
+
+// handlerGReaderToken hands out the write token clients echo back in T.
+// Requests already carry their credentials in a header no browser sends
+// on its own, so the token is not checked.
+func (s *Server) handlerGReaderToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, strings.ReplaceAll(user.ID.String(), "-", ""))
+}
+
+func (s *Server) handlerGReaderUserInfo(w http.ResponseWriter, r *http.Request, user database.User) {
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"userId":        user.ID.String(),
+		"userName":      user.Name,
+		"userProfileId": user.ID.String(),
+		"userEmail":     user.Name,
+	})
+}
+
+type greaderCategory struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type greaderSubscription struct {
+	ID         string            `json:"id"`
+	Title      string            `json:"title"`
+	Categories []greaderCategory `json:"categories"`
+	Url        string            `json:"url"`
+	HtmlUrl    string            `json:"htmlUrl"`
+	IconUrl    string            `json:"iconUrl"`
+}
+
+func (s *Server) handlerGReaderSubscriptions(w http.ResponseWriter, r *http.Request, user database.User) {
+	subs, err := s.db.GetUnreadCountsForUser(r.Context(), user.ID)
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+	resp := []greaderSubscription{}
+	for _, sub := range subs {
+		gs := greaderSubscription{
+			ID:         feedPrefix + sub.FeedID.String(),
+			Title:      sub.FeedName,
+			Categories: []greaderCategory{},
+			Url:        sub.FeedUrl,
+			HtmlUrl:    sub.SiteUrl.String,
+		}
+		if sub.FolderName.Valid {
+			gs.Categories = append(gs.Categories, greaderCategory{ID: labelPrefix + sub.FolderName.String, Label: sub.FolderName.String})
+		}
+		resp = append(resp, gs)
+	}
+	respondWithJSON(w, http.StatusOK, map[string]any{"subscriptions": resp})
+}
+
+func (s *Server) handlerGReaderTags(w http.ResponseWriter, r *http.Request, user database.User) {
+	folders, err := s.db.GetFoldersForUser(r.Context(), user.ID)
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+	type tag struct {
+		ID   string `json:"id"`
+		Type string `json:"type,omitempty"`
+	}
+	tags := []tag{{ID: streamStarred}}
+	for _, f := range folders {
+		tags = append(tags, tag{ID: labelPrefix + f.Name, Type: "folder"})
+	}
+	respondWithJSON(w, http.StatusOK, map[string]any{"tags": tags})
+}
+
+func (s *Server) handlerGReaderUnreadCount(w http.ResponseWriter, r *http.Request, user database.User) {
+	subs, err := s.db.GetUnreadCountsForUser(r.Context(), user.ID)
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+	type count struct {
+		ID                      string `json:"id"`
+		Count                   int64  `json:"count"`
+		NewestItemTimestampUsec string `json:"newestItemTimestampUsec"`
+	}
+	now := strconv.FormatInt(time.Now().UnixMicro(), 10)
+	var total int64
+	folders := map[string]int64{}
+	counts := []count{}
+	for _, sub := range subs {
+		counts = append(counts, count{ID: feedPrefix + sub.FeedID.String(), Count: sub.Unread, NewestItemTimestampUsec: now})
+		if sub.Hidden {
+			continue
+		}
+		if sub.FolderName.Valid {
+			folders[sub.FolderName.String] += sub.Unread
+		}
+		total += sub.Unread
+	}
+	for name, n := range folders {
+		counts = append(counts, count{ID: labelPrefix + name, Count: n, NewestItemTimestampUsec: now})
+	}
+	counts = append(counts, count{ID: streamReadingList, Count: total, NewestItemTimestampUsec: now})
+	respondWithJSON(w, http.StatusOK, map[string]any{"max": total, "unreadcounts": counts})
+}
+
+func (s *Server) handlerGReaderItemIDs(w http.ResponseWriter, r *http.Request, user database.User) {
+	args, err := streamQuery(r, r.FormValue("s"), user, greaderMaxIDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ids, err := s.db.GetItemIDsForUser(r.Context(), args)
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+
+	type itemRef struct {
+		ID string `json:"id"`
+	}
+	refs := []itemRef{}
+	for _, id := range ids {
+		refs = append(refs, itemRef{ID: strconv.FormatInt(id, 10)})
+	}
+	resp := map[string]any{"itemRefs": refs}
+	if len(ids) == int(args.MaxResults) {
+		resp["continuation"] = strconv.FormatInt(ids[len(ids)-1], 10)
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handlerGReaderItemContents(w http.ResponseWriter, r *http.Request, user database.User) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ids, err := parseItemIDs(r.Form["i"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	items, err := s.db.GetItemsForUser(r.Context(), database.GetItemsForUserParams{UserID: user.ID, Ids: ids})
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newGReaderStream(streamReadingList, items, ""))
+}
+
+func (s *Server) handlerGReaderStreamContents(w http.ResponseWriter, r *http.Request, user database.User) {
+	stream := r.PathValue("stream")
+	if stream == "" {
+		stream = r.FormValue("s")
+	}
+	args, err := streamQuery(r, stream, user, greaderMaxItems)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ids, err := s.db.GetItemIDsForUser(r.Context(), args)
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+	items, err := s.db.GetItemsForUser(r.Context(), database.GetItemsForUserParams{UserID: user.ID, Ids: ids})
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+	if args.OldestFirst {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	continuation := ""
+	if len(ids) == int(args.MaxResults) {
+		continuation = strconv.FormatInt(ids[len(ids)-1], 10)
+	}
+	respondWithJSON(w, http.StatusOK, newGReaderStream(stream, items, continuation))
+}
+
+func (s *Server) handlerGReaderEditTag(w http.ResponseWriter, r *http.Request, user database.User) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ids, err := parseItemIDs(r.Form["i"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	for _, tag := range r.Form["a"] {
+		switch normalizeStream(tag) {
+		case streamRead:
+			err = s.db.MarkItemsRead(ctx, database.MarkItemsReadParams{UserID: user.ID, Ids: ids})
+		case streamKeptUnread:
+			err = s.db.MarkItemsUnread(ctx, database.MarkItemsUnreadParams{UserID: user.ID, Ids: ids})
+		case streamStarred:
+			err = s.db.StarItems(ctx, database.StarItemsParams{UserID: user.ID, Ids: ids})
+		}
+		if err != nil {
+			s.webError(w, err)
+			return
+		}
+	}
+	for _, tag := range r.Form["r"] {
+		switch normalizeStream(tag) {
+		case streamRead:
+			err = s.db.MarkItemsUnread(ctx, database.MarkItemsUnreadParams{UserID: user.ID, Ids: ids})
+		case streamStarred:
+			err = s.db.UnstarItems(ctx, database.UnstarItemsParams{UserID: user.ID, Ids: ids})
+		}
+		if err != nil {
+			s.webError(w, err)
+			return
+		}
+	}
+	io.WriteString(w, "OK")
+}
+
+func (s *Server) handlerGReaderMarkAllRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	filter, err := parseStream(r.FormValue("s"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	args := database.MarkFeedItemsReadParams{UserID: user.ID, FeedID: filter.feedID, Folder: filter.folder, Before: time.Now()}
+	if ts := r.FormValue("ts"); ts != "" {
+		usec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid ts", http.StatusBadRequest)
+			return
+		}
+		args.Before = time.UnixMicro(usec)
+	}
+	if _, err := s.db.MarkFeedItemsRead(r.Context(), args); err != nil {
+		s.webError(w, err)
+		return
+	}
+	io.WriteString(w, "OK")
+}
+
+// streamFilter is what a Google Reader stream id selects.
+type streamFilter struct {
+	feedID  uuid.NullUUID
+	folder  sql.NullString
+	starred bool
+	read    bool
+}
+
+// normalizeStream replaces the user id in user/<id>/... stream ids with
+// the - every client also accepts.
+func normalizeStream(id string) string {
+	parts := strings.SplitN(id, "/", 3)
+	if len(parts) == 3 && parts[0] == "user" {
+		return "user/-/" + parts[2]
+	}
+	return id
+}
+
+func parseStream(id string) (streamFilter, error) {
+	id = normalizeStream(id)
+	switch {
+	case id == "" || id == streamReadingList:
+		return streamFilter{}, nil
+	case id == streamStarred:
+		return streamFilter{starred: true}, nil
+	case id == streamRead:
+		return streamFilter{read: true}, nil
+	case strings.HasPrefix(id, labelPrefix):
+		return streamFilter{folder: sql.NullString{String: strings.TrimPrefix(id, labelPrefix), Valid: true}}, nil
+	case strings.HasPrefix(id, feedPrefix):
+		feedID, err := uuid.Parse(strings.TrimPrefix(id, feedPrefix))
+		if err != nil {
+			return streamFilter{}, fmt.Errorf("unknown feed %s", id)
+		}
+		return streamFilter{feedID: uuid.NullUUID{UUID: feedID, Valid: true}}, nil
+	}
+	return streamFilter{}, fmt.Errorf("unknown stream %s", id)
+}
+
+// streamQuery turns a stream id and the usual n, r, c, ot, nt, xt and it
+// parameters into a query for the ids in it.
+func streamQuery(r *http.Request, stream string, user database.User, max int) (database.GetItemIDsForUserParams, error) {
+	filter, err := parseStream(stream)
+	if err != nil {
+		return database.GetItemIDsForUserParams{}, err
+	}
+	args := database.GetItemIDsForUserParams{
+		UserID:      user.ID,
+		FeedID:      filter.feedID,
+		Folder:      filter.folder,
+		StarredOnly: filter.starred,
+		ReadOnly:    filter.read,
+		OldestFirst: r.FormValue("r") == "o",
+		MaxResults:  greaderDefaultItems,
+	}
+	if n := r.FormValue("n"); n != "" {
+		limit, err := strconv.Atoi(n)
+		if err != nil || limit <= 0 {
+			return args, errors.New("invalid n")
+		}
+		args.MaxResults = int32(min(limit, max))
+	}
+	for _, xt := range r.Form["xt"] {
+		if normalizeStream(xt) == streamRead {
+			args.UnreadOnly = true
+		}
+	}
+	for _, it := range r.Form["it"] {
+		switch normalizeStream(it) {
+		case streamStarred:
+			args.StarredOnly = true
+		case streamRead:
+			args.ReadOnly = true
+		}
+	}
+	if ot := r.FormValue("ot"); ot != "" {
+		sec, err := strconv.ParseInt(ot, 10, 64)
+		if err != nil {
+			return args, errors.New("invalid ot")
+		}
+		args.Since = sql.NullTime{Time: time.Unix(sec, 0), Valid: true}
+	}
+	if nt := r.FormValue("nt"); nt != "" {
+		sec, err := strconv.ParseInt(nt, 10, 64)
+		if err != nil {
+			return args, errors.New("invalid nt")
+		}
+		args.Until = sql.NullTime{Time: time.Unix(sec, 0), Valid: true}
+	}
+	if c := r.FormValue("c"); c != "" {
+		last, err := strconv.ParseInt(c, 10, 64)
+		if err != nil {
+			return args, errors.New("invalid continuation")
+		}
+		if args.OldestFirst {
+			args.MinID = sql.NullInt64{Int64: last, Valid: true}
+		} else {
+			args.MaxID = sql.NullInt64{Int64: last, Valid: true}
+		}
+	}
+	return args, nil
+}
+
+// parseItemIDs accepts item ids in decimal or in the long hex form.
+func parseItemIDs(values []string) ([]int64, error) {
+	ids := make([]int64, 0, len(values))
+	for _, v := range values {
+		var id int64
+		var err error
+		if hex, ok := strings.CutPrefix(v, longItemPrefix); ok {
+			var u uint64
+			u, err = strconv.ParseUint(hex, 16, 64)
+			if u > math.MaxInt64 {
+				err = strconv.ErrRange
+			}
+			id = int64(u)
+		} else {
+			id, err = strconv.ParseInt(v, 10, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid item id %s", v)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+type greaderLink struct {
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+}
+
+type greaderItem struct {
+	ID            string        `json:"id"`
+	CrawlTimeMsec string        `json:"crawlTimeMsec"`
+	TimestampUsec string        `json:"timestampUsec"`
+	Published     int64         `json:"published"`
+	Updated       int64         `json:"updated"`
+	Title         string        `json:"title"`
+	Author        string        `json:"author,omitempty"`
+	Canonical     []greaderLink `json:"canonical"`
+	Alternate     []greaderLink `json:"alternate"`
+	Categories    []string      `json:"categories"`
+	Origin        struct {
+		StreamID string `json:"streamId"`
+		Title    string `json:"title"`
+		HtmlUrl  string `json:"htmlUrl"`
+	} `json:"origin"`
+	Summary struct {
+		Direction string `json:"direction"`
+		Content   string `json:"content"`
+	} `json:"summary"`
+}
+
+func newGReaderStream(id string, items []database.GetItemsForUserRow, continuation string) map[string]any {
+	resp := map[string]any{
+		"direction": "ltr",
+		"id":        id,
+		"updated":   time.Now().Unix(),
+	}
+	out := []greaderItem{}
+	for _, i := range items {
+		published := i.CreatedAt
+		if i.PublishedAt.Valid {
+			published = i.PublishedAt.Time
+		}
+		gi := greaderItem{
+			ID:            fmt.Sprintf("%s%016x", longItemPrefix, i.IntID),
+			CrawlTimeMsec: strconv.FormatInt(i.CreatedAt.UnixMilli(), 10),
+			TimestampUsec: strconv.FormatInt(i.CreatedAt.UnixMicro(), 10),
+			Published:     published.Unix(),
+			Updated:       published.Unix(),
+			Title:         i.Title,
+			Author:        i.Author.String,
+			Canonical:     []greaderLink{{Href: i.Url}},
+			Alternate:     []greaderLink{{Href: i.Url, Type: "text/html"}},
+			Categories:    []string{streamReadingList},
+		}
+		if i.FolderName.Valid {
+			gi.Categories = append(gi.Categories, labelPrefix+i.FolderName.String)
+		}
+		if i.IsRead {
+			gi.Categories = append(gi.Categories, streamRead)
+		}
+		if i.IsStarred {
+			gi.Categories = append(gi.Categories, streamStarred)
+		}
+		gi.Origin.StreamID = feedPrefix + i.FeedID.String()
+		gi.Origin.Title = i.FeedName
+		gi.Origin.HtmlUrl = i.SiteUrl.String
+		body := i.Content.String
+		if !i.Content.Valid {
+			body = i.Description.String
+		}
+		gi.Summary.Direction = "ltr"
+		gi.Summary.Content = content.Sanitize(body)
+		out = append(out, gi)
+	}
+	resp["items"] = out
+	if continuation != "" {
+		resp["continuation"] = continuation
+	}
+	return resp
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/timeline"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// handlerPostsList serves the user's timeline with the same filters as
+// gator browse, one keyset page at a time.
+func (s *Server) handlerPostsList(w http.ResponseWriter, r *http.Request, user database.User) {
+	q := r.URL.Query()
+	limit, err := pageSize(q)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetPostsForUserParams{
+		UserID:      user.ID,
+		FeedUrl:     nullString(q.Get("feed")),
+		Folder:      nullString(q.Get("folder")),
+		UnreadOnly:  q.Get("unread") == "true",
+		StarredOnly: q.Get("starred") == "true",
+		Category:    nullString(q.Get("category")),
+		Author:      nullString(q.Get("author")),
+		Tag:         nullString(q.Get("tag")),
+		MaxResults:  int32(limit),
+	}
+	if args.Since, err = queryTime(q, "since"); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if args.Until, err = queryTime(q, "until"); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if after := q.Get("after"); after != "" {
+		c, err := timeline.ParseCursor(after)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		args.AfterCreatedAt = sql.NullTime{Time: c.CreatedAt, Valid: true}
+		args.AfterID = uuid.NullUUID{UUID: c.ID, Valid: true}
+	}
+
+	posts, err := s.db.GetPostsForUser(r.Context(), args)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	page := PostPage{Posts: []Post{}}
+	for _, p := range posts {
+		page.Posts = append(page.Posts, newPost(p))
+	}
+	if len(posts) == limit {
+		last := posts[len(posts)-1]
+		page.NextCursor = timeline.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func (s *Server) handlerPostsSearch(w http.ResponseWriter, r *http.Request, user database.User) {
+	q := r.URL.Query()
+	if q.Get("q") == "" {
+		respondWithError(w, http.StatusBadRequest, "missing q parameter")
+		return
+	}
+	limit, err := pageSize(q)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.SearchPostsForUserParams{
+		Query:      q.Get("q"),
+		UserID:     user.ID,
+		FeedUrl:    nullString(q.Get("feed")),
+		MaxResults: int32(limit),
+	}
+	if args.Since, err = queryTime(q, "since"); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, err := s.db.SearchPostsForUser(r.Context(), args)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	resp := []SearchResult{}
+	for _, res := range results {
+		resp = append(resp, SearchResult{
+			ID:          res.ID,
+			Title:       res.Title,
+			Url:         res.Url,
+			Feed:        res.FeedName,
+			PublishedAt: nullTime(res.PublishedAt),
+			Rank:        res.Rank,
+			Headline:    res.Headline,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handlerPostsRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid post id")
+		return
+	}
+	// only posts in feeds the user follows
+	if _, err := s.db.GetPostForUser(r.Context(), database.GetPostForUserParams{ID: postID, UserID: user.ID}); err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	err = s.db.MarkPostRead(r.Context(), database.MarkPostReadParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, PostID: postID})
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlerPostsStar(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid post id")
+		return
+	}
+	// only posts in feeds the user follows
+	if _, err := s.db.GetPostForUser(r.Context(), database.GetPostForUserParams{ID: postID, UserID: user.ID}); err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	_, err = s.db.CreateSavedPost(r.Context(), database.CreateSavedPostParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, PostID: postID})
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		// starring is idempotent
+		err = nil
+	}
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlerPostsUnstar(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid post id")
+		return
+	}
+	_, err = s.db.DeleteSavedPost(r.Context(), database.DeleteSavedPostParams{UserID: user.ID, PostID: postID})
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlerStarredList(w http.ResponseWriter, r *http.Request, user database.User) {
+	posts, err := s.db.GetSavedPostsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	resp := []SavedPost{}
+	for _, p := range posts {
+		resp = append(resp, SavedPost{
+			ID:          p.ID,
+			Title:       p.Title,
+			Url:         p.Url,
+			Description: p.Description.String,
+			PublishedAt: nullTime(p.PublishedAt),
+			SavedAt:     p.SavedAt,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func pageSize(q url.Values) (int, error) {
+	if q.Get("limit") == "" {
+		return defaultPageSize, nil
+	}
+	n, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || n <= 0 || n > maxPageSize {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+	return n, nil
+}
+
+// queryTime parses the named query parameter as an RFC 3339 timestamp or a
+// plain date.
+func queryTime(q url.Values, name string) (sql.NullTime, error) {
+	v := q.Get(name)
+	if v == "" {
+		return sql.NullTime{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return sql.NullTime{Time: t, Valid: true}, nil
+		}
+	}
+	return sql.NullTime{}, errors.New(name + " must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
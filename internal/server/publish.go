@@ -0,0 +1,62 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/rss"
+)
+
+const publishedFeedSize = 50
+
+func (s *Server) handlerPublishAtom(w http.ResponseWriter, r *http.Request) {
+	s.publish(w, r, "application/atom+xml", rss.WriteAtom)
+}
+
+func (s *Server) handlerPublishRSS(w http.ResponseWriter, r *http.Request) {
+	s.publish(w, r, "application/rss+xml", rss.WriteRSS)
+}
+
+// publish serves a user's merged timeline, or one of their folders when
+// the folder query parameter is set, as a feed, the same way gator publish
+// writes it to a file.
+func (s *Server) publish(w http.ResponseWriter, r *http.Request, contentType string, write func(io.Writer, rss.Channel, []database.Post) error) {
+	user, err := s.db.GetUser(r.Context(), r.PathValue("name"))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+
+	folder := r.URL.Query().Get("folder")
+	args := database.GetPublishedPostsForUserParams{UserID: user.ID, Folder: nullString(folder), MaxResults: publishedFeedSize}
+	ch := rss.Channel{
+		Title:       fmt.Sprintf("%s's gator timeline", user.Name),
+		Link:        "http://" + r.Host + r.URL.RequestURI(),
+		Description: fmt.Sprintf("Posts from the feeds %s follows", user.Name),
+		ID:          "urn:uuid:" + user.ID.String(),
+	}
+	if folder != "" {
+		ch.Title = fmt.Sprintf("%s's %s feeds", user.Name, folder)
+		ch.Description = fmt.Sprintf("Posts from the feeds in %s's %s folder", user.Name, folder)
+		ch.ID += "/" + folder
+	}
+
+	posts, err := s.db.GetPublishedPostsForUser(r.Context(), args)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	if err := write(w, ch, posts); err != nil {
+		log.Printf("could not write feed: %s", err)
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/auth"
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/events"
+	"github.com/lib/pq"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// Server exposes gator's data over HTTP. Every request is served from the
+// same database.Queries the CLI uses. hub delivers posts as gator agg
+// stores them, without it the event stream is unavailable.
+type Server struct {
+	db   *database.Queries
+	conn *sql.DB
+	hub  *events.Hub
+	mux  *http.ServeMux
+}
+
+func New(conn *sql.DB, hub *events.Hub) *Server {
+	s := &Server{db: database.New(conn), conn: conn, hub: hub, mux: http.NewServeMux()}
+	s.routes()
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /api/openapi.json", handlerOpenAPI)
+
+	s.mux.HandleFunc("GET /api/users", s.handlerUsersList)
+	s.mux.HandleFunc("POST /api/users", s.handlerUsersCreate)
+	s.mux.HandleFunc("POST /api/login", s.handlerLogin)
+	s.mux.HandleFunc("DELETE /api/users/{name}", s.middlewareAdmin(s.handlerUsersDelete))
+	s.mux.HandleFunc("PUT /api/users/{name}/admin", s.middlewareAdmin(s.handlerUsersGrantAdmin))
+	s.mux.HandleFunc("DELETE /api/users/{name}/admin", s.middlewareAdmin(s.handlerUsersRevokeAdmin))
+	s.mux.HandleFunc("GET /api/me", s.middlewareAuth(s.handlerMe))
+
+	s.mux.HandleFunc("GET /api/feeds", s.handlerFeedsList)
+	s.mux.HandleFunc("POST /api/feeds", s.middlewareAuth(s.handlerFeedsCreate))
+	s.mux.HandleFunc("DELETE /api/feeds/{feedID}", s.middlewareAuth(s.handlerFeedsDelete))
+	s.mux.HandleFunc("PUT /api/feeds/{feedID}/retention", s.middlewareAuth(s.handlerFeedsRetention))
+
+	s.mux.HandleFunc("GET /api/follows", s.middlewareAuth(s.handlerFollowsList))
+	s.mux.HandleFunc("POST /api/follows", s.middlewareAuth(s.handlerFollowsCreate))
+	s.mux.HandleFunc("DELETE /api/follows/{feedID}", s.middlewareAuth(s.handlerFollowsDelete))
+
+	s.mux.HandleFunc("GET /api/posts", s.middlewareAuth(s.handlerPostsList))
+	s.mux.HandleFunc("GET /api/posts/search", s.middlewareAuth(s.handlerPostsSearch))
+	s.mux.HandleFunc("POST /api/posts/{postID}/read", s.middlewareAuth(s.handlerPostsRead))
+	s.mux.HandleFunc("PUT /api/posts/{postID}/star", s.middlewareAuth(s.handlerPostsStar))
+	s.mux.HandleFunc("DELETE /api/posts/{postID}/star", s.middlewareAuth(s.handlerPostsUnstar))
+	s.mux.HandleFunc("GET /api/starred", s.middlewareAuth(s.handlerStarredList))
+	s.mux.HandleFunc("GET /api/events", s.middlewareAuth(s.handlerEvents))
+
+	s.mux.HandleFunc("GET /users/{name}/feed.atom", s.handlerPublishAtom)
+	s.mux.HandleFunc("GET /users/{name}/feed.rss", s.handlerPublishRSS)
+
+	s.webRoutes()
+	s.greaderRoutes()
+	s.feverRoutes()
+}
+
+func handlerOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// middlewareAuth resolves the user making the request from its bearer
+// token and passes it to handler, answering 401 when there is none.
+func (s *Server) middlewareAuth(handler func(http.ResponseWriter, *http.Request, database.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		user, err := s.userForToken(r.Context(), token)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusUnauthorized, "invalid or revoked token")
+				return
+			}
+			respondWithDBError(w, err)
+			return
+		}
+		handler(w, r, user)
+	}
+}
+
+// userForToken looks up the owner of an API token and records that the
+// token was used.
+func (s *Server) userForToken(ctx context.Context, token string) (database.User, error) {
+	hash := auth.HashToken(token)
+	user, err := s.db.GetUserByToken(ctx, hash)
+	if err != nil {
+		return database.User{}, err
+	}
+	err = s.db.MarkAPITokenUsed(ctx, database.MarkAPITokenUsedParams{TokenHash: hash, LastUsedAt: sql.NullTime{Time: time.Now(), Valid: true}})
+	if err != nil {
+		log.Printf("error marking token used: %s", err)
+	}
+	return user, nil
+}
+
+// middlewareAdmin is middlewareAuth for endpoints only admins may use,
+// answering 403 to everyone else.
+func (s *Server) middlewareAdmin(handler func(http.ResponseWriter, *http.Request, database.User)) http.HandlerFunc {
+	return s.middlewareAuth(func(w http.ResponseWriter, r *http.Request, user database.User) {
+		if !user.IsAdmin {
+			respondWithError(w, http.StatusForbidden, "admin only")
+			return
+		}
+		handler(w, r, user)
+	})
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("error marshalling JSON: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+func respondWithError(w http.ResponseWriter, code int, msg string) {
+	respondWithJSON(w, code, errorResponse{Error: msg})
+}
+
+// respondWithDBError maps errors from database.Queries onto HTTP statuses.
+func respondWithDBError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code {
+		case "23505":
+			respondWithError(w, http.StatusConflict, "already exists")
+			return
+		case "23503":
+			respondWithError(w, http.StatusNotFound, "referenced resource does not exist")
+			return
+		}
+	}
+	log.Printf("database error: %s", err)
+	respondWithError(w, http.StatusInternalServerError, "internal error")
+}
+
+func decodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	IsAdmin   bool      `json:"is_admin"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Feed struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	Url           string     `json:"url"`
+	SiteUrl       string     `json:"site_url,omitempty"`
+	CreatedBy     string     `json:"created_by,omitempty"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type Follow struct {
+	FeedID      uuid.UUID `json:"feed_id"`
+	FeedName    string    `json:"feed_name"`
+	FeedUrl     string    `json:"feed_url"`
+	DisplayName string    `json:"display_name,omitempty"`
+	Folder      string    `json:"folder,omitempty"`
+	Notify      bool      `json:"notify"`
+	Hidden      bool      `json:"hidden"`
+}
+
+type Post struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Url         string     `json:"url"`
+	Feed        string     `json:"feed"`
+	Author      string     `json:"author,omitempty"`
+	Categories  []string   `json:"categories"`
+	Description string     `json:"description,omitempty"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type PostPage struct {
+	Posts      []Post `json:"posts"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type SearchResult struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Url         string     `json:"url"`
+	Feed        string     `json:"feed"`
+	PublishedAt *time.Time `json:"published_at"`
+	Rank        float32    `json:"rank"`
+	Headline    string     `json:"headline"`
+}
+
+type SavedPost struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Url         string     `json:"url"`
+	Description string     `json:"description,omitempty"`
+	PublishedAt *time.Time `json:"published_at"`
+	SavedAt     time.Time  `json:"saved_at"`
+}
+
+func newUser(u database.User) User {
+	return User{ID: u.ID, Name: u.Name, IsAdmin: u.IsAdmin, CreatedAt: u.CreatedAt}
+}
+
+func newPost(p database.GetPostsForUserRow) Post {
+	post := Post{
+		ID:          p.ID,
+		Title:       p.Title,
+		Url:         p.Url,
+		Feed:        p.FeedName,
+		Author:      p.Author.String,
+		Categories:  p.Categories,
+		Description: p.Description.String,
+		PublishedAt: nullTime(p.PublishedAt),
+		CreatedAt:   p.CreatedAt,
+	}
+	if post.Categories == nil {
+		post.Categories = []string{}
+	}
+	return post
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/auth"
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/google/uuid"
+)
+
+type credentials struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+func (s *Server) handlerUsersList(w http.ResponseWriter, r *http.Request) {
+	users, err := s.db.GetUsers(r.Context())
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	resp := []User{}
+	for _, u := range users {
+		resp = append(resp, newUser(u))
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handlerUsersCreate(w http.ResponseWriter, r *http.Request) {
+	var params credentials
+	if err := decodeJSON(r, &params); err != nil || params.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "body must be {\"name\": string, \"password\": string}")
+		return
+	}
+	if len(params.Password) < 8 {
+		respondWithError(w, http.StatusBadRequest, "password must be at least 8 characters")
+		return
+	}
+
+	hash, err := auth.HashPassword(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	user, err := s.db.CreateUser(r.Context(), database.CreateUserParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), Name: params.Name, HashedPassword: sql.NullString{String: hash, Valid: true}})
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, newUser(user))
+}
+
+// errBadCredentials is returned by login for an unknown user or a wrong
+// password, which callers must not tell apart.
+var errBadCredentials = errors.New("incorrect name or password")
+
+// handlerLogin checks a user's password and hands out a new API token for
+// the bearer header.
+func (s *Server) handlerLogin(w http.ResponseWriter, r *http.Request) {
+	var params credentials
+	if err := decodeJSON(r, &params); err != nil || params.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "body must be {\"name\": string, \"password\": string}")
+		return
+	}
+
+	token, err := s.login(r.Context(), params, "api login")
+	if errors.Is(err, errBadCredentials) {
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, struct {
+		Token string `json:"token"`
+	}{Token: token})
+}
+
+// login checks creds and creates a new API token for the user, named
+// after where it was issued.
+func (s *Server) login(ctx context.Context, creds credentials, via string) (string, error) {
+	user, err := s.db.GetUser(ctx, creds.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errBadCredentials
+	}
+	if err != nil {
+		return "", err
+	}
+	if !user.HashedPassword.Valid || auth.CheckPasswordHash(creds.Password, user.HashedPassword.String) != nil {
+		return "", errBadCredentials
+	}
+
+	token, err := auth.MakeToken()
+	if err != nil {
+		return "", err
+	}
+	_, err = s.db.CreateAPIToken(ctx, database.CreateAPITokenParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		Name:      via + " " + time.Now().Format(time.RFC3339Nano),
+		TokenHash: auth.HashToken(token),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// handlerUsersDelete removes a user. Feeds they added are handed to the
+// admin so other followers keep them.
+func (s *Server) handlerUsersDelete(w http.ResponseWriter, r *http.Request, admin database.User) {
+	name := r.PathValue("name")
+	if name == admin.Name {
+		respondWithError(w, http.StatusBadRequest, "you can't delete yourself")
+		return
+	}
+	target, err := s.db.GetUser(r.Context(), name)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	tx, err := s.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	defer tx.Rollback()
+	q := s.db.WithTx(tx)
+	err = q.ReassignFeeds(r.Context(), database.ReassignFeedsParams{ToUserID: admin.ID, UpdatedAt: time.Now(), FromUserID: target.ID})
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	_, err = q.DeleteUser(r.Context(), name)
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlerUsersGrantAdmin(w http.ResponseWriter, r *http.Request, admin database.User) {
+	s.setAdmin(w, r, r.PathValue("name"), true)
+}
+
+func (s *Server) handlerUsersRevokeAdmin(w http.ResponseWriter, r *http.Request, admin database.User) {
+	if r.PathValue("name") == admin.Name {
+		respondWithError(w, http.StatusBadRequest, "you can't revoke your own admin role")
+		return
+	}
+	s.setAdmin(w, r, r.PathValue("name"), false)
+}
+
+func (s *Server) setAdmin(w http.ResponseWriter, r *http.Request, name string, isAdmin bool) {
+	n, err := s.db.SetUserAdmin(r.Context(), database.SetUserAdminParams{Name: name, IsAdmin: isAdmin, UpdatedAt: time.Now()})
+	if err != nil {
+		respondWithDBError(w, err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlerMe(w http.ResponseWriter, r *http.Request, user database.User) {
+	respondWithJSON(w, http.StatusOK, newUser(user))
+}
@@ -0,0 +1,332 @@
+package server
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/brinwiththevlin/aggregator/internal/auth"
+	"github.com/brinwiththevlin/aggregator/internal/content"
+	"github.com/brinwiththevlin/aggregator/internal/database"
+	"github.com/brinwiththevlin/aggregator/internal/timeline"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+//go:embed web
+var webFS embed.FS
+
+const (
+	sessionCookie = "gator_session"
+	webPageSize   = 30
+)
+
+var webTemplates = map[string]*template.Template{
+	"login":    parseWebTemplate("login.html"),
+	"timeline": parseWebTemplate("timeline.html"),
+	"post":     parseWebTemplate("post.html"),
+}
+
+func parseWebTemplate(page string) *template.Template {
+	funcs := template.FuncMap{
+		"date": func(published sql.NullTime, created time.Time) string {
+			if published.Valid {
+				return published.Time.Format("2006-01-02 15:04")
+			}
+			return created.Format("2006-01-02 15:04")
+		},
+	}
+	return template.Must(template.New("").Funcs(funcs).ParseFS(webFS, "web/layout.html", "web/"+page))
+}
+
+func (s *Server) webRoutes() {
+	static, err := fs.Sub(webFS, "web/static")
+	if err != nil {
+		panic(err)
+	}
+	s.mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServerFS(static)))
+
+	s.mux.HandleFunc("GET /login", s.handlerWebLoginForm)
+	s.mux.HandleFunc("POST /login", s.handlerWebLogin)
+	s.mux.HandleFunc("POST /logout", s.handlerWebLogout)
+
+	s.mux.HandleFunc("GET /{$}", s.middlewareSession(s.handlerWebTimeline))
+	s.mux.HandleFunc("GET /posts/{postID}", s.middlewareSession(s.handlerWebPost))
+	s.mux.HandleFunc("POST /posts/{postID}/read", s.middlewareSession(s.handlerWebRead))
+	s.mux.HandleFunc("POST /posts/{postID}/star", s.middlewareSession(s.handlerWebStar))
+	s.mux.HandleFunc("POST /posts/{postID}/unstar", s.middlewareSession(s.handlerWebUnstar))
+}
+
+// middlewareSession is middlewareAuth for the web UI: the token comes from
+// the session cookie set at login, and visitors without one are sent to
+// the login form.
+func (s *Server) middlewareSession(handler func(http.ResponseWriter, *http.Request, database.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookie)
+		if err != nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		user, err := s.userForToken(r.Context(), cookie.Value)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		if err != nil {
+			s.webError(w, err)
+			return
+		}
+		handler(w, r, user)
+	}
+}
+
+// webPage is the data every web template gets. Pages fill in the parts
+// they show.
+type webPage struct {
+	User    database.User
+	Here    string
+	Error   string
+	Folders []webFolder
+	Unread  int64
+
+	// Timeline
+	Feed       string
+	UnreadOnly bool
+	Posts      []database.GetPostsForUserRow
+	NextPage   string
+
+	// Article view
+	Post database.GetPostForUserRow
+	Body template.HTML
+}
+
+type webFolder struct {
+	Name  string
+	Feeds []database.GetUnreadCountsForUserRow
+}
+
+func (s *Server) render(w http.ResponseWriter, name string, page webPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := webTemplates[name].ExecuteTemplate(w, "layout", page); err != nil {
+		log.Printf("error rendering %s: %s", name, err)
+	}
+}
+
+func (s *Server) webError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("database error: %s", err)
+	http.Error(w, "internal error", http.StatusInternalServerError)
+}
+
+// sidebar loads the followed feeds with their unread counts, grouped by
+// folder the same way gator following groups them.
+func (s *Server) sidebar(r *http.Request, user database.User, page *webPage) error {
+	counts, err := s.db.GetUnreadCountsForUser(r.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+	for _, c := range counts {
+		if len(page.Folders) == 0 || page.Folders[len(page.Folders)-1].Name != c.FolderName.String {
+			page.Folders = append(page.Folders, webFolder{Name: c.FolderName.String})
+		}
+		f := &page.Folders[len(page.Folders)-1]
+		f.Feeds = append(f.Feeds, c)
+		if !c.Hidden {
+			page.Unread += c.Unread
+		}
+	}
+	return nil
+}
+
+func (s *Server) handlerWebLoginForm(w http.ResponseWriter, r *http.Request) {
+	s.render(w, "login", webPage{})
+}
+
+func (s *Server) handlerWebLogin(w http.ResponseWriter, r *http.Request) {
+	creds := credentials{Name: r.FormValue("name"), Password: r.FormValue("password")}
+	token, err := s.login(r.Context(), creds, "web login")
+	if errors.Is(err, errBadCredentials) {
+		w.WriteHeader(http.StatusUnauthorized)
+		s.render(w, "login", webPage{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		// Strict keeps other sites from posting the read and star forms
+		// with the user's session.
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) handlerWebLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		args := database.RevokeAPITokenByHashParams{TokenHash: auth.HashToken(cookie.Value), RevokedAt: sql.NullTime{Time: time.Now(), Valid: true}}
+		if err := s.db.RevokeAPITokenByHash(r.Context(), args); err != nil {
+			log.Printf("error revoking session: %s", err)
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// handlerWebTimeline lists posts newest first, for one feed when the
+// sidebar asked for it, using the same query as gator browse.
+func (s *Server) handlerWebTimeline(w http.ResponseWriter, r *http.Request, user database.User) {
+	q := r.URL.Query()
+	page := webPage{User: user, Here: r.URL.RequestURI(), Feed: q.Get("feed"), UnreadOnly: q.Get("unread") == "1"}
+	args := database.GetPostsForUserParams{
+		UserID:     user.ID,
+		FeedUrl:    nullString(page.Feed),
+		UnreadOnly: page.UnreadOnly,
+		MaxResults: webPageSize,
+	}
+	if after := q.Get("after"); after != "" {
+		c, err := timeline.ParseCursor(after)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		args.AfterCreatedAt = sql.NullTime{Time: c.CreatedAt, Valid: true}
+		args.AfterID = uuid.NullUUID{UUID: c.ID, Valid: true}
+	}
+
+	posts, err := s.db.GetPostsForUser(r.Context(), args)
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+	page.Posts = posts
+	if len(posts) == webPageSize {
+		last := posts[len(posts)-1]
+		next := url.Values{"after": {timeline.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()}}
+		if page.Feed != "" {
+			next.Set("feed", page.Feed)
+		}
+		if page.UnreadOnly {
+			next.Set("unread", "1")
+		}
+		page.NextPage = "/?" + next.Encode()
+	}
+	if err := s.sidebar(r, user, &page); err != nil {
+		s.webError(w, err)
+		return
+	}
+	s.render(w, "timeline", page)
+}
+
+// handlerWebPost shows one post with its sanitized content and marks it
+// read.
+func (s *Server) handlerWebPost(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		http.Error(w, "invalid post id", http.StatusBadRequest)
+		return
+	}
+	post, err := s.db.GetPostForUser(r.Context(), database.GetPostForUserParams{ID: postID, UserID: user.ID})
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+	if !post.IsRead {
+		err = s.db.MarkPostRead(r.Context(), database.MarkPostReadParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, PostID: postID})
+		if err != nil {
+			s.webError(w, err)
+			return
+		}
+	}
+
+	body := post.Content.String
+	if !post.Content.Valid {
+		body = post.Description.String
+	}
+	page := webPage{User: user, Here: r.URL.RequestURI(), Feed: post.FeedUrl, Post: post, Body: template.HTML(content.Sanitize(body))}
+	if err := s.sidebar(r, user, &page); err != nil {
+		s.webError(w, err)
+		return
+	}
+	s.render(w, "post", page)
+}
+
+func (s *Server) handlerWebRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		http.Error(w, "invalid post id", http.StatusBadRequest)
+		return
+	}
+	// only posts in feeds the user follows
+	if _, err := s.db.GetPostForUser(r.Context(), database.GetPostForUserParams{ID: postID, UserID: user.ID}); err != nil {
+		s.webError(w, err)
+		return
+	}
+	err = s.db.MarkPostRead(r.Context(), database.MarkPostReadParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, PostID: postID})
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+	redirectBack(w, r)
+}
+
+func (s *Server) handlerWebStar(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		http.Error(w, "invalid post id", http.StatusBadRequest)
+		return
+	}
+	// only posts in feeds the user follows
+	if _, err := s.db.GetPostForUser(r.Context(), database.GetPostForUserParams{ID: postID, UserID: user.ID}); err != nil {
+		s.webError(w, err)
+		return
+	}
+	_, err = s.db.CreateSavedPost(r.Context(), database.CreateSavedPostParams{ID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now(), UserID: user.ID, PostID: postID})
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		err = nil
+	}
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+	redirectBack(w, r)
+}
+
+func (s *Server) handlerWebUnstar(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		http.Error(w, "invalid post id", http.StatusBadRequest)
+		return
+	}
+	_, err = s.db.DeleteSavedPost(r.Context(), database.DeleteSavedPostParams{UserID: user.ID, PostID: postID})
+	if err != nil {
+		s.webError(w, err)
+		return
+	}
+	redirectBack(w, r)
+}
+
+// redirectBack returns to the page a form was posted from, taken from its
+// next field. Only local paths are followed.
+func redirectBack(w http.ResponseWriter, r *http.Request) {
+	next := r.FormValue("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		next = "/"
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
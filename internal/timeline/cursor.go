@@ -0,0 +1,47 @@
+package timeline
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cursor marks a position in a user's timeline. Timelines are ordered by
+// post creation time and then id, newest first, so a cursor holding the
+// last post of one page identifies where the next page starts.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Encode returns the opaque string form of the cursor.
+func (c Cursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor decodes a cursor produced by Encode.
+func ParseCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+
+	c := Cursor{}
+	c.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+	c.ID, err = uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+	return c, nil
+}
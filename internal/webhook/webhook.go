@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Headers sent with every delivery. The signature is the hex HMAC-SHA256
+// of the request body keyed with the webhook's secret, prefixed with
+// "sha256=".
+const (
+	SignatureHeader = "X-Gator-Signature"
+	EventHeader     = "X-Gator-Event"
+	DeliveryHeader  = "X-Gator-Delivery"
+)
+
+// Payload is the JSON body posted to a webhook. Event is "post" for new
+// posts and "test" for gator webhook test.
+type Payload struct {
+	Event   string `json:"event"`
+	Webhook string `json:"webhook"`
+	Post    Post   `json:"post"`
+}
+
+type Post struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Url         string     `json:"url"`
+	Feed        string     `json:"feed"`
+	FeedUrl     string     `json:"feed_url"`
+	Author      string     `json:"author,omitempty"`
+	Categories  []string   `json:"categories"`
+	Description string     `json:"description,omitempty"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// NewSecret returns a random secret for signing a webhook's deliveries.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send posts payload to url and returns the response status. Anything but
+// a 2xx answer is an error, the status is returned with it when there was
+// one.
+func Send(ctx context.Context, url, secret string, deliveryID uuid.UUID, payload Payload) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set(EventHeader, payload.Event)
+	req.Header.Set(DeliveryHeader, deliveryID.String())
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook answered %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
@@ -0,0 +1,54 @@
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (id, created_at, updated_at, user_id, name, token_hash)
+    VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING
+    *;
+
+-- name: GetUserByToken :one
+SELECT
+    u.*
+FROM
+    users u
+    INNER JOIN api_tokens t ON t.user_id = u.id
+WHERE
+    t.token_hash = $1
+    AND t.revoked_at IS NULL;
+
+-- name: MarkAPITokenUsed :exec
+UPDATE
+    api_tokens
+SET
+    last_used_at = $2
+WHERE
+    token_hash = $1;
+
+-- name: GetAPITokensForUser :many
+SELECT
+    *
+FROM
+    api_tokens
+WHERE
+    user_id = $1
+ORDER BY
+    created_at;
+
+-- name: RevokeAPIToken :execrows
+UPDATE
+    api_tokens
+SET
+    revoked_at = $3,
+    updated_at = $3
+WHERE
+    user_id = $1
+    AND name = $2
+    AND revoked_at IS NULL;
+
+-- name: RevokeAPITokenByHash :exec
+UPDATE
+    api_tokens
+SET
+    revoked_at = $2,
+    updated_at = $2
+WHERE
+    token_hash = $1
+    AND revoked_at IS NULL;
@@ -0,0 +1,106 @@
+-- name: SetDigest :one
+INSERT INTO digests (user_id, created_at, updated_at, email, frequency)
+    VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id)
+    DO UPDATE SET
+        email = EXCLUDED.email,
+        frequency = EXCLUDED.frequency,
+        updated_at = EXCLUDED.updated_at
+    RETURNING
+        *;
+
+-- name: GetDigest :one
+SELECT
+    *
+FROM
+    digests
+WHERE
+    user_id = $1;
+
+-- name: DeleteDigest :execrows
+DELETE FROM digests
+WHERE user_id = $1;
+
+-- name: GetDueDigests :many
+SELECT
+    d.user_id,
+    d.email,
+    d.frequency,
+    d.last_sent_at,
+    u.name AS user_name
+FROM
+    digests d
+    INNER JOIN users u ON u.id = d.user_id
+WHERE
+    d.last_sent_at IS NULL
+    OR d.last_sent_at <= sqlc.arg(now)::timestamp - CASE d.frequency
+    WHEN 'weekly' THEN
+        interval '7 days'
+    ELSE
+        interval '1 day'
+    END;
+
+-- name: GetDigestPosts :many
+SELECT
+    p.id,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.created_at,
+    p.author,
+    COALESCE(ff.display_name, f.name) AS feed_name
+FROM
+    digests d
+    INNER JOIN feed_follows ff ON ff.user_id = d.user_id
+    INNER JOIN feeds f ON f.id = ff.feed_id
+    INNER JOIN posts p ON p.feed_id = f.id
+WHERE
+    d.user_id = sqlc.arg(user_id)
+    AND ff.notify
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            hidden_posts hp
+        WHERE
+            hp.post_id = p.id
+            AND hp.user_id = d.user_id)
+    AND p.created_at > d.created_at
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            read_posts rp
+        WHERE
+            rp.post_id = p.id
+            AND rp.user_id = d.user_id)
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            digest_posts dp
+        WHERE
+            dp.post_id = p.id
+            AND dp.user_id = d.user_id)
+ORDER BY
+    feed_name,
+    p.created_at DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: MarkDigestSent :exec
+WITH sent AS (
+INSERT INTO digest_posts (user_id, post_id, sent_at)
+    SELECT
+        sqlc.arg(user_id)::uuid,
+        unnest(sqlc.arg(post_ids)::uuid[]),
+        sqlc.arg(now)::timestamp
+    ON CONFLICT
+        DO NOTHING)
+UPDATE
+    digests
+SET
+    last_sent_at = sqlc.arg(now)::timestamp,
+    updated_at = sqlc.arg(now)::timestamp
+WHERE
+    user_id = sqlc.arg(user_id)::uuid;
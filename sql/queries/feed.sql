@@ -1,6 +1,6 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
-    VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, site_url)
+    VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING
     *;
 
@@ -12,6 +12,14 @@ FROM
     feeds f
     INNER JOIN users u ON f.user_id = u.id;
 
+-- name: GetFeed :one
+SELECT
+    *
+FROM
+    feeds
+WHERE
+    id = $1;
+
 -- name: GetFeedByUrl :one
 SELECT
     *
@@ -38,3 +46,34 @@ ORDER BY
     feeds.last_fetched_at NULLS FIRST
 LIMIT 1;
 
+
+-- name: SetFeedRetention :exec
+UPDATE
+    feeds
+SET
+    updated_at = $2,
+    retention_max_age_days = $3,
+    retention_max_posts = $4
+WHERE
+    id = $1;
+
+-- name: SetFeedSiteUrl :exec
+UPDATE
+    feeds
+SET
+    site_url = $2
+WHERE
+    id = $1;
+
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
+
+-- name: ReassignFeeds :exec
+UPDATE
+    feeds
+SET
+    user_id = sqlc.arg(to_user_id),
+    updated_at = sqlc.arg(updated_at)
+WHERE
+    user_id = sqlc.arg(from_user_id);
@@ -0,0 +1,45 @@
+-- name: CreateFolder :one
+INSERT INTO folders (id, created_at, updated_at, user_id, name)
+    VALUES ($1, $2, $3, $4, $5)
+RETURNING
+    *;
+
+-- name: GetFolderByName :one
+SELECT
+    *
+FROM
+    folders
+WHERE
+    user_id = $1
+    AND name = $2;
+
+-- name: GetFoldersForUser :many
+SELECT
+    fo.id,
+    fo.name,
+    COUNT(ff.id) AS follow_count
+FROM
+    folders fo
+    LEFT JOIN feed_follows ff ON ff.folder_id = fo.id
+WHERE
+    fo.user_id = $1
+GROUP BY
+    fo.id,
+    fo.name
+ORDER BY
+    fo.name;
+
+-- name: RenameFolder :execrows
+UPDATE
+    folders
+SET
+    name = sqlc.arg(new_name),
+    updated_at = sqlc.arg(updated_at)
+WHERE
+    user_id = sqlc.arg(user_id)
+    AND name = sqlc.arg(name);
+
+-- name: DeleteFolder :execrows
+DELETE FROM folders
+WHERE user_id = $1
+    AND name = $2;
@@ -1,7 +1,7 @@
 -- name: CreateFeedFollow :one
 WITH inserted AS (
-INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-        VALUES ($1, $2, $3, $4, $5)
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, display_name, notify, hidden)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
     RETURNING
         *)
     SELECT
@@ -16,15 +16,105 @@ INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
 -- name: GetFeedFollowForUser :many
 SELECT
     f.name AS feed_name,
-    u.name AS useer_name
+    u.name AS useer_name,
+    f.url AS feed_url,
+    fo.name AS folder_name,
+    o.display_name,
+    o.notify,
+    o.hidden,
+    f.site_url,
+    o.feed_id
 FROM
     feed_follows o
     LEFT JOIN users u ON o.user_id = u.id
     LEFT JOIN feeds f ON o.feed_id = f.id
+    LEFT JOIN folders fo ON o.folder_id = fo.id
 WHERE
-    u.id = $1;
+    u.id = $1
+ORDER BY
+    fo.name NULLS FIRST,
+    f.name;
+
+-- name: GetUnreadCountsForUser :many
+SELECT
+    ff.feed_id,
+    f.int_id AS feed_int_id,
+    COALESCE(ff.display_name, f.name) AS feed_name,
+    f.url AS feed_url,
+    f.site_url,
+    fo.name AS folder_name,
+    fo.int_id AS folder_int_id,
+    ff.hidden,
+    count(p.id) AS unread
+FROM
+    feed_follows ff
+    INNER JOIN feeds f ON ff.feed_id = f.id
+    LEFT JOIN folders fo ON ff.folder_id = fo.id
+    LEFT JOIN posts p ON p.feed_id = f.id
+        AND NOT EXISTS (
+            SELECT
+                1
+            FROM
+                read_posts rp
+            WHERE
+                rp.post_id = p.id
+                AND rp.user_id = ff.user_id)
+        AND NOT EXISTS (
+            SELECT
+                1
+            FROM
+                hidden_posts hp
+            WHERE
+                hp.post_id = p.id
+                AND hp.user_id = ff.user_id)
+WHERE
+    ff.user_id = $1
+GROUP BY
+    ff.feed_id,
+    f.int_id,
+    ff.display_name,
+    f.name,
+    f.url,
+    f.site_url,
+    fo.name,
+    fo.int_id,
+    ff.hidden
+ORDER BY
+    fo.name NULLS FIRST,
+    feed_name;
+
+-- name: GetFeedFollow :one
+SELECT
+    *
+FROM
+    feed_follows
+WHERE
+    user_id = $1
+    AND feed_id = $2;
 
 -- name: DeleteFeedFollow :exec
 Delete from feed_follows
 where user_id = $1 and feed_id = $2;
 
+
+-- name: SetFeedFollowFolder :execrows
+UPDATE
+    feed_follows
+SET
+    folder_id = $3,
+    updated_at = $4
+WHERE
+    user_id = $1
+    AND feed_id = $2;
+
+-- name: UpdateFeedFollowSettings :exec
+UPDATE
+    feed_follows
+SET
+    display_name = $3,
+    notify = $4,
+    hidden = $5,
+    updated_at = $6
+WHERE
+    user_id = $1
+    AND feed_id = $2;
@@ -0,0 +1,226 @@
+-- name: CountItemsForUser :one
+-- every item the Fever API can list, for its total_items
+SELECT
+    count(*)
+FROM
+    posts p
+    INNER JOIN feed_follows ff ON ff.feed_id = p.feed_id
+WHERE
+    ff.user_id = $1
+    AND NOT ff.hidden
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            hidden_posts hp
+        WHERE
+            hp.post_id = p.id
+            AND hp.user_id = ff.user_id);
+
+-- Queries for the Google Reader and Fever APIs, which address posts by
+-- their integer int_id.
+-- name: GetItemIDsForUser :many
+SELECT
+    p.int_id
+FROM
+    posts p
+    INNER JOIN feed_follows ff ON ff.feed_id = p.feed_id
+WHERE
+    ff.user_id = sqlc.arg(user_id)
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            hidden_posts hp
+        WHERE
+            hp.post_id = p.id
+            AND hp.user_id = ff.user_id)
+    AND (sqlc.narg(feed_id)::uuid IS NULL
+        OR ff.feed_id = sqlc.narg(feed_id))
+    AND (NOT ff.hidden
+        OR ff.feed_id = sqlc.narg(feed_id)
+        OR sqlc.arg(starred_only)::boolean)
+    AND (sqlc.narg(folder)::text IS NULL
+        OR ff.folder_id IN (
+            SELECT
+                fo.id
+            FROM
+                folders fo
+            WHERE
+                fo.user_id = ff.user_id
+                AND fo.name = sqlc.narg(folder)))
+    AND (NOT sqlc.arg(unread_only)::boolean
+        OR NOT EXISTS (
+            SELECT
+                1
+            FROM
+                read_posts rp
+            WHERE
+                rp.post_id = p.id
+                AND rp.user_id = ff.user_id))
+    AND (NOT sqlc.arg(read_only)::boolean
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                read_posts rp
+            WHERE
+                rp.post_id = p.id
+                AND rp.user_id = ff.user_id))
+    AND (NOT sqlc.arg(starred_only)::boolean
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                saved_posts sp
+            WHERE
+                sp.post_id = p.id
+                AND sp.user_id = ff.user_id))
+    AND (sqlc.narg(since)::timestamp IS NULL
+        OR p.created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamp IS NULL
+        OR p.created_at < sqlc.narg(until))
+    AND (sqlc.narg(min_id)::bigint IS NULL
+        OR p.int_id > sqlc.narg(min_id))
+    AND (sqlc.narg(max_id)::bigint IS NULL
+        OR p.int_id < sqlc.narg(max_id))
+ORDER BY
+    CASE WHEN sqlc.arg(oldest_first)::boolean THEN
+        p.int_id
+    END ASC,
+    p.int_id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: GetItemsForUser :many
+SELECT
+    p.int_id,
+    p.id,
+    p.title,
+    p.url,
+    p.description,
+    p.content,
+    p.published_at,
+    p.created_at,
+    p.author,
+    p.categories,
+    f.id AS feed_id,
+    f.int_id AS feed_int_id,
+    COALESCE(ff.display_name, f.name) AS feed_name,
+    f.url AS feed_url,
+    f.site_url,
+    fo.name AS folder_name,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            read_posts rp
+        WHERE
+            rp.post_id = p.id
+            AND rp.user_id = ff.user_id) AS is_read,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            saved_posts sp
+        WHERE
+            sp.post_id = p.id
+            AND sp.user_id = ff.user_id) AS is_starred
+FROM
+    posts p
+    INNER JOIN feeds f ON p.feed_id = f.id
+    INNER JOIN feed_follows ff ON ff.feed_id = f.id
+    LEFT JOIN folders fo ON ff.folder_id = fo.id
+WHERE
+    ff.user_id = sqlc.arg(user_id)
+    AND p.int_id = ANY (sqlc.arg(ids)::bigint[])
+ORDER BY
+    p.int_id DESC;
+
+-- name: MarkItemsRead :exec
+INSERT INTO read_posts (id, created_at, updated_at, user_id, post_id)
+SELECT
+    gen_random_uuid (),
+    now(),
+    now(),
+    sqlc.arg(user_id)::uuid,
+    p.id
+FROM
+    posts p
+    INNER JOIN feed_follows ff ON ff.feed_id = p.feed_id
+        AND ff.user_id = sqlc.arg(user_id)
+WHERE
+    p.int_id = ANY (sqlc.arg(ids)::bigint[])
+ON CONFLICT (user_id,
+    post_id)
+    DO NOTHING;
+
+-- name: MarkItemsUnread :exec
+DELETE FROM read_posts
+WHERE user_id = sqlc.arg(user_id)
+    AND post_id IN (
+        SELECT
+            p.id
+        FROM
+            posts p
+        WHERE
+            p.int_id = ANY (sqlc.arg(ids)::bigint[]));
+
+-- name: MarkFeedItemsRead :execrows
+-- marks everything the user sees in a feed, a folder or the whole
+-- timeline as read, up to a point in time
+INSERT INTO read_posts (id, created_at, updated_at, user_id, post_id)
+SELECT
+    gen_random_uuid (),
+    now(),
+    now(),
+    ff.user_id,
+    p.id
+FROM
+    posts p
+    INNER JOIN feed_follows ff ON ff.feed_id = p.feed_id
+WHERE
+    ff.user_id = sqlc.arg(user_id)
+    AND (sqlc.narg(feed_id)::uuid IS NULL
+        OR ff.feed_id = sqlc.narg(feed_id))
+    AND (sqlc.narg(folder)::text IS NULL
+        OR ff.folder_id IN (
+            SELECT
+                fo.id
+            FROM
+                folders fo
+            WHERE
+                fo.user_id = ff.user_id
+                AND fo.name = sqlc.narg(folder)))
+    AND p.created_at <= sqlc.arg(before)
+ON CONFLICT (user_id,
+    post_id)
+    DO NOTHING;
+
+-- name: StarItems :exec
+INSERT INTO saved_posts (id, created_at, updated_at, user_id, post_id)
+SELECT
+    gen_random_uuid (),
+    now(),
+    now(),
+    sqlc.arg(user_id)::uuid,
+    p.id
+FROM
+    posts p
+    INNER JOIN feed_follows ff ON ff.feed_id = p.feed_id
+        AND ff.user_id = sqlc.arg(user_id)
+WHERE
+    p.int_id = ANY (sqlc.arg(ids)::bigint[])
+ON CONFLICT (user_id,
+    post_id)
+    DO NOTHING;
+
+-- name: UnstarItems :exec
+DELETE FROM saved_posts
+WHERE user_id = sqlc.arg(user_id)
+    AND post_id IN (
+        SELECT
+            p.id
+        FROM
+            posts p
+        WHERE
+            p.int_id = ANY (sqlc.arg(ids)::bigint[]));
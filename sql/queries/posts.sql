@@ -1,22 +1,288 @@
 -- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-    VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content, author, categories)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING
     *;
 
 -- name: GetPostsForUser :many
 SELECT
+    p.id,
+    p.int_id,
     p.title,
     p.url,
     p.description,
-    p.published_at
+    p.published_at,
+    p.created_at,
+    p.author,
+    p.categories,
+    COALESCE(ff.display_name, f.name) AS feed_name
 FROM
     posts p
-    LEFT JOIN feeds f ON p.feed_id = f.id
-    LEFT JOIN users u ON f.user_id = u.id
+    INNER JOIN feeds f ON p.feed_id = f.id
+    INNER JOIN feed_follows ff ON ff.feed_id = f.id
 WHERE
-    u.id = $1
+    ff.user_id = sqlc.arg(user_id)
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            hidden_posts hp
+        WHERE
+            hp.post_id = p.id
+            AND hp.user_id = ff.user_id)
+    AND (sqlc.narg(feed_url)::text IS NULL
+        OR f.url = sqlc.narg(feed_url))
+    AND (NOT ff.hidden
+        OR f.url = sqlc.narg(feed_url))
+    AND (sqlc.narg(folder)::text IS NULL
+        OR ff.folder_id IN (
+            SELECT
+                fo.id
+            FROM
+                folders fo
+            WHERE
+                fo.user_id = ff.user_id
+                AND fo.name = sqlc.narg(folder)))
+    AND (sqlc.narg(since)::timestamp IS NULL
+        OR COALESCE(p.published_at, p.created_at) >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamp IS NULL
+        OR COALESCE(p.published_at, p.created_at) < sqlc.narg(until))
+    AND (NOT sqlc.arg(unread_only)::boolean
+        OR NOT EXISTS (
+            SELECT
+                1
+            FROM
+                read_posts rp
+            WHERE
+                rp.post_id = p.id
+                AND rp.user_id = ff.user_id))
+    AND (NOT sqlc.arg(starred_only)::boolean
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                saved_posts sp
+            WHERE
+                sp.post_id = p.id
+                AND sp.user_id = ff.user_id))
+    AND (sqlc.narg(category)::text IS NULL
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                unnest(p.categories) c
+            WHERE
+                lower(c) = lower(sqlc.narg(category))))
+    AND (sqlc.narg(author)::text IS NULL
+        OR p.author ILIKE '%' || sqlc.narg(author) || '%')
+    AND (sqlc.narg(tag)::text IS NULL
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                post_tags pt
+                INNER JOIN tags t ON t.id = pt.tag_id
+            WHERE
+                pt.post_id = p.id
+                AND t.user_id = ff.user_id
+                AND t.name = sqlc.narg(tag)))
+    AND (sqlc.narg(after_created_at)::timestamp IS NULL
+        OR (p.created_at, p.id) < (sqlc.narg(after_created_at), sqlc.narg(after_id)::uuid))
 ORDER BY
-    p.created_at DESC
-LIMIT $2;
+    p.created_at DESC,
+    p.id DESC
+LIMIT sqlc.arg(max_results);
 
+-- name: GetPostForUser :one
+SELECT
+    p.id,
+    p.int_id,
+    p.title,
+    p.url,
+    p.description,
+    p.content,
+    p.published_at,
+    p.created_at,
+    p.author,
+    p.categories,
+    COALESCE(ff.display_name, f.name) AS feed_name,
+    f.url AS feed_url,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            read_posts rp
+        WHERE
+            rp.post_id = p.id
+            AND rp.user_id = ff.user_id) AS is_read,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            saved_posts sp
+        WHERE
+            sp.post_id = p.id
+            AND sp.user_id = ff.user_id) AS is_starred
+FROM
+    posts p
+    INNER JOIN feeds f ON p.feed_id = f.id
+    INNER JOIN feed_follows ff ON ff.feed_id = f.id
+WHERE
+    p.id = $1
+    AND ff.user_id = $2;
+
+-- name: GetPostIDByIntID :one
+SELECT
+    id
+FROM
+    posts
+WHERE
+    int_id = $1;
+
+-- name: MarkPostRead :exec
+INSERT INTO read_posts (id, created_at, updated_at, user_id, post_id)
+    VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, post_id)
+    DO NOTHING;
+
+-- name: MarkPostUnread :exec
+DELETE FROM read_posts
+WHERE user_id = $1
+    AND post_id = $2;
+
+-- name: GetPostStatesForUser :many
+SELECT
+    p.id,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            read_posts rp
+        WHERE
+            rp.post_id = p.id
+            AND rp.user_id = sqlc.arg(user_id)) AS is_read,
+    EXISTS (
+        SELECT
+            1
+        FROM
+            saved_posts sp
+        WHERE
+            sp.post_id = p.id
+            AND sp.user_id = sqlc.arg(user_id)) AS is_starred
+FROM
+    posts p
+WHERE
+    p.id = ANY (sqlc.arg(post_ids)::uuid[]);
+
+-- name: NotifyNewPost :exec
+SELECT
+    pg_notify('gator_new_posts', sqlc.arg(payload)::text);
+
+-- name: PrunePosts :execrows
+WITH ranked AS (
+    SELECT
+        p.id,
+        COALESCE(p.published_at, p.created_at) AS posted_at,
+        ROW_NUMBER() OVER (PARTITION BY p.feed_id ORDER BY COALESCE(p.published_at, p.created_at) DESC) AS position,
+        COALESCE(f.retention_max_age_days, sqlc.arg(max_age_days)::int) AS max_age_days,
+        COALESCE(f.retention_max_posts, sqlc.arg(max_posts)::int) AS max_posts
+    FROM
+        posts p
+        INNER JOIN feeds f ON p.feed_id = f.id
+)
+DELETE FROM posts
+USING ranked r
+WHERE posts.id = r.id
+    AND ((r.max_age_days > 0
+            AND r.posted_at < NOW() - make_interval(days => r.max_age_days))
+        OR (r.max_posts > 0
+            AND r.position > r.max_posts))
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            saved_posts s
+        WHERE
+            s.post_id = posts.id)
+    AND NOT (posts.created_at > NOW() - make_interval(days => sqlc.arg(keep_unread_days)::int)
+        AND EXISTS (
+            SELECT
+                1
+            FROM
+                feed_follows ff
+            WHERE
+                ff.feed_id = posts.feed_id
+                AND NOT EXISTS (
+                    SELECT
+                        1
+                    FROM
+                        read_posts rp
+                    WHERE
+                        rp.post_id = posts.id
+                        AND rp.user_id = ff.user_id)));
+
+-- name: SearchPostsForUser :many
+SELECT
+    p.id,
+    p.int_id,
+    p.title,
+    p.url,
+    p.published_at,
+    COALESCE(ff.display_name, f.name) AS feed_name,
+    ts_rank(p.search_vector, q)::real AS rank,
+    ts_headline('english', COALESCE(p.description, p.content, ''), q, 'MaxFragments=2, StartSel=**, StopSel=**') AS headline
+FROM
+    posts p
+    INNER JOIN feeds f ON p.feed_id = f.id
+    INNER JOIN feed_follows ff ON ff.feed_id = f.id,
+    websearch_to_tsquery('english', sqlc.arg(query)) q
+WHERE
+    ff.user_id = sqlc.arg(user_id)
+    AND p.search_vector @@ q
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            hidden_posts hp
+        WHERE
+            hp.post_id = p.id
+            AND hp.user_id = ff.user_id)
+    AND (sqlc.narg(feed_url)::text IS NULL
+        OR f.url = sqlc.narg(feed_url))
+    AND (sqlc.narg(since)::timestamp IS NULL
+        OR COALESCE(p.published_at, p.created_at) >= sqlc.narg(since))
+ORDER BY
+    rank DESC,
+    COALESCE(p.published_at, p.created_at) DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: GetPublishedPostsForUser :many
+SELECT
+    p.*
+FROM
+    posts p
+    INNER JOIN feed_follows ff ON ff.feed_id = p.feed_id
+WHERE
+    ff.user_id = sqlc.arg(user_id)
+    AND NOT ff.hidden
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            hidden_posts hp
+        WHERE
+            hp.post_id = p.id
+            AND hp.user_id = ff.user_id)
+    AND (sqlc.narg(folder)::text IS NULL
+        OR ff.folder_id IN (
+            SELECT
+                fo.id
+            FROM
+                folders fo
+            WHERE
+                fo.user_id = ff.user_id
+                AND fo.name = sqlc.narg(folder)))
+ORDER BY
+    p.created_at DESC,
+    p.id DESC
+LIMIT sqlc.arg(max_results);
@@ -0,0 +1,55 @@
+-- name: CreateRule :one
+INSERT INTO rules (id, created_at, updated_at, user_id, name, field, is_regex, pattern, action, tag)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING
+    *;
+
+-- name: GetRulesForUser :many
+SELECT
+    *
+FROM
+    rules
+WHERE
+    user_id = $1
+ORDER BY
+    name;
+
+-- name: DeleteRule :execrows
+DELETE FROM rules
+WHERE user_id = $1
+    AND name = $2;
+
+-- name: GetRulesForFeed :many
+SELECT
+    r.*
+FROM
+    rules r
+    INNER JOIN feed_follows ff ON ff.user_id = r.user_id
+WHERE
+    ff.feed_id = $1
+ORDER BY
+    r.user_id,
+    r.created_at;
+
+-- name: HidePost :exec
+INSERT INTO hidden_posts (user_id, post_id, rule_id, created_at)
+    VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, post_id)
+    DO NOTHING;
+
+-- name: GetPostsForRules :many
+SELECT
+    p.id,
+    p.title,
+    p.description,
+    p.author,
+    p.categories
+FROM
+    posts p
+    INNER JOIN feed_follows ff ON ff.feed_id = p.feed_id
+WHERE
+    ff.user_id = sqlc.arg(user_id)
+    AND p.id > sqlc.arg(after_id)
+ORDER BY
+    p.id
+LIMIT sqlc.arg(max_results);
@@ -0,0 +1,27 @@
+-- name: CreateSavedPost :one
+INSERT INTO saved_posts (id, created_at, updated_at, user_id, post_id)
+    VALUES ($1, $2, $3, $4, $5)
+RETURNING
+    *;
+
+-- name: DeleteSavedPost :execrows
+DELETE FROM saved_posts
+WHERE user_id = $1
+    AND post_id = $2;
+
+-- name: GetSavedPostsForUser :many
+SELECT
+    p.id,
+    p.int_id,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    s.created_at AS saved_at
+FROM
+    saved_posts s
+    INNER JOIN posts p ON s.post_id = p.id
+WHERE
+    s.user_id = $1
+ORDER BY
+    s.created_at DESC;
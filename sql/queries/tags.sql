@@ -0,0 +1,55 @@
+-- name: TagPost :exec
+-- used by rules with the tag action and by gator tag, creating the tag
+-- on first use
+WITH tag AS (
+INSERT INTO tags (id, created_at, updated_at, user_id, name)
+        VALUES (gen_random_uuid (), sqlc.arg(now), sqlc.arg(now), sqlc.arg(user_id), sqlc.arg(tag))
+    ON CONFLICT (user_id, name)
+        DO UPDATE SET
+            name = EXCLUDED.name
+        RETURNING
+            id)
+    INSERT INTO post_tags (tag_id, post_id, created_at)
+    SELECT
+        tag.id,
+        sqlc.arg(post_id)::uuid,
+        sqlc.arg(now)::timestamp
+    FROM
+        tag
+    ON CONFLICT
+        DO NOTHING;
+
+-- name: UntagPost :execrows
+DELETE FROM post_tags pt USING tags t
+WHERE t.id = pt.tag_id
+    AND t.user_id = $1
+    AND pt.post_id = $2
+    AND t.name = $3;
+
+-- name: GetTagsForUser :many
+SELECT
+    t.name,
+    count(pt.post_id) AS posts
+FROM
+    tags t
+    INNER JOIN post_tags pt ON pt.tag_id = t.id
+WHERE
+    t.user_id = $1
+GROUP BY
+    t.id,
+    t.name
+ORDER BY
+    t.name;
+
+-- name: GetTagsForPosts :many
+SELECT
+    pt.post_id,
+    t.name
+FROM
+    post_tags pt
+    INNER JOIN tags t ON t.id = pt.tag_id
+WHERE
+    t.user_id = sqlc.arg(user_id)
+    AND pt.post_id = ANY (sqlc.arg(post_ids)::uuid[])
+ORDER BY
+    t.name;
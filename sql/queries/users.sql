@@ -1,6 +1,13 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
-    VALUES ($1, $2, $3, $4)
+-- the first user to register while there is no admin becomes one
+INSERT INTO users (id, created_at, updated_at, name, hashed_password, is_admin)
+    VALUES ($1, $2, $3, $4, $5, NOT EXISTS (
+            SELECT
+                1
+            FROM
+                users
+            WHERE
+                is_admin))
 RETURNING
     *;
 
@@ -18,6 +25,45 @@ SELECT
 FROM
     users;
 
+-- name: SetUserPassword :exec
+UPDATE
+    users
+SET
+    hashed_password = $2,
+    updated_at = $3
+WHERE
+    id = $1;
+
+-- name: GetUserByFeverKey :one
+SELECT
+    *
+FROM
+    users
+WHERE
+    fever_api_key = $1;
+
+-- name: SetUserFeverKey :exec
+UPDATE
+    users
+SET
+    fever_api_key = $2,
+    updated_at = $3
+WHERE
+    id = $1;
+
+-- name: SetUserAdmin :execrows
+UPDATE
+    users
+SET
+    is_admin = $2,
+    updated_at = $3
+WHERE
+    name = $1;
+
+-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE name = $1;
+
 -- name: Reset :exec
 DELETE FROM users;
 
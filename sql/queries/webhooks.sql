@@ -0,0 +1,147 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (id, created_at, updated_at, user_id, name, url, secret, feed_id, folder_id, keyword)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING
+    *;
+
+-- name: GetWebhook :one
+SELECT
+    *
+FROM
+    webhooks
+WHERE
+    user_id = $1
+    AND name = $2;
+
+-- name: GetWebhooksForUser :many
+SELECT
+    w.name,
+    w.url,
+    f.url AS feed_url,
+    fo.name AS folder_name,
+    w.keyword,
+    (
+        SELECT
+            count(*)
+        FROM
+            webhook_deliveries d
+        WHERE
+            d.webhook_id = w.id
+            AND d.status = 'delivered') AS delivered,
+    (
+        SELECT
+            count(*)
+        FROM
+            webhook_deliveries d
+        WHERE
+            d.webhook_id = w.id
+            AND d.status = 'failed') AS failed
+FROM
+    webhooks w
+    LEFT JOIN feeds f ON f.id = w.feed_id
+    LEFT JOIN folders fo ON fo.id = w.folder_id
+WHERE
+    w.user_id = $1
+ORDER BY
+    w.name;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE user_id = $1
+    AND name = $2;
+
+-- name: QueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (created_at, updated_at, webhook_id, post_id, next_attempt_at)
+SELECT
+    sqlc.arg(now)::timestamp,
+    sqlc.arg(now)::timestamp,
+    w.id,
+    p.id,
+    sqlc.arg(now)::timestamp
+FROM
+    webhooks w
+    INNER JOIN posts p ON p.id = sqlc.arg(post_id)
+    INNER JOIN feed_follows ff ON ff.user_id = w.user_id
+        AND ff.feed_id = p.feed_id
+WHERE
+    ff.notify
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            hidden_posts hp
+        WHERE
+            hp.post_id = p.id
+            AND hp.user_id = w.user_id)
+    AND (w.feed_id IS NULL
+        OR w.feed_id = p.feed_id)
+    AND (w.folder_id IS NULL
+        OR w.folder_id = ff.folder_id)
+    AND (w.keyword IS NULL
+        OR strpos(lower(p.title || ' ' || COALESCE(p.description, '') || ' ' || COALESCE(p.content, '')), lower(w.keyword)) > 0)
+ON CONFLICT
+    DO NOTHING;
+
+-- name: GetDueWebhookDeliveries :many
+SELECT
+    d.id,
+    d.attempts,
+    w.name AS webhook_name,
+    w.url AS webhook_url,
+    w.secret,
+    p.id AS post_id,
+    p.title,
+    p.url,
+    p.description,
+    p.author,
+    p.categories,
+    p.published_at,
+    p.created_at,
+    f.name AS feed_name,
+    f.url AS feed_url
+FROM
+    webhook_deliveries d
+    INNER JOIN webhooks w ON w.id = d.webhook_id
+    INNER JOIN posts p ON p.id = d.post_id
+    INNER JOIN feeds f ON f.id = p.feed_id
+WHERE
+    d.status = 'pending'
+    AND d.next_attempt_at <= sqlc.arg(now)
+ORDER BY
+    d.next_attempt_at
+LIMIT sqlc.arg(max_results);
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE
+    webhook_deliveries
+SET
+    status = $2,
+    attempts = $3,
+    next_attempt_at = $4,
+    response_code = $5,
+    error = $6,
+    updated_at = $7
+WHERE
+    id = $1;
+
+-- name: LogWebhookDelivery :exec
+INSERT INTO webhook_deliveries (id, created_at, updated_at, webhook_id, status, attempts, next_attempt_at, response_code, error)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: GetWebhookDeliveries :many
+SELECT
+    d.created_at,
+    d.updated_at,
+    d.status,
+    d.attempts,
+    d.response_code,
+    d.error,
+    p.title AS post_title
+FROM
+    webhook_deliveries d
+    LEFT JOIN posts p ON p.id = d.post_id
+WHERE
+    d.webhook_id = sqlc.arg(webhook_id)
+ORDER BY
+    d.created_at DESC
+LIMIT sqlc.arg(max_results);
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE saved_posts (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at timestamp NOT NULL,
+    updated_at timestamp NOT NULL,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    post_id uuid NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    UNIQUE(user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE saved_posts;
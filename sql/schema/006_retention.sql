@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE feeds
+    ADD COLUMN retention_max_age_days integer,
+    ADD COLUMN retention_max_posts integer;
+
+CREATE TABLE read_posts (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at timestamp NOT NULL,
+    updated_at timestamp NOT NULL,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    post_id uuid NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    UNIQUE(user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE read_posts;
+
+ALTER TABLE feeds
+    DROP COLUMN retention_max_age_days,
+    DROP COLUMN retention_max_posts;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE posts
+    ADD COLUMN author TEXT,
+    ADD COLUMN categories TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX posts_created_at_id_idx ON posts (created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX posts_created_at_id_idx;
+
+ALTER TABLE posts
+    DROP COLUMN categories,
+    DROP COLUMN author;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE folders (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at timestamp NOT NULL,
+    updated_at timestamp NOT NULL,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    UNIQUE(user_id, name)
+);
+
+ALTER TABLE feed_follows
+    ADD COLUMN folder_id uuid REFERENCES folders (id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE feed_follows
+    DROP COLUMN folder_id;
+
+DROP TABLE folders;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE feed_follows
+    ADD COLUMN display_name text,
+    ADD COLUMN notify boolean NOT NULL DEFAULT TRUE,
+    ADD COLUMN hidden boolean NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE feed_follows
+    DROP COLUMN display_name,
+    DROP COLUMN notify,
+    DROP COLUMN hidden;
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN hashed_password text;
+
+CREATE TABLE api_tokens (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at timestamp NOT NULL,
+    updated_at timestamp NOT NULL,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    token_hash text UNIQUE NOT NULL,
+    last_used_at timestamp,
+    revoked_at timestamp,
+    UNIQUE(user_id, name)
+);
+
+-- +goose Down
+DROP TABLE api_tokens;
+
+ALTER TABLE users
+    DROP COLUMN hashed_password;
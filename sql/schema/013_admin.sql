@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN is_admin boolean NOT NULL DEFAULT FALSE;
+
+-- the oldest user with a password has been running things so far, make
+-- them the first admin. Users without one can't prove who they are, when
+-- nobody has a password the next user to register becomes admin instead
+UPDATE
+    users
+SET
+    is_admin = TRUE
+WHERE
+    id = (
+        SELECT
+            id
+        FROM
+            users
+        WHERE
+            hashed_password IS NOT NULL
+        ORDER BY
+            created_at
+        LIMIT 1);
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN is_admin;
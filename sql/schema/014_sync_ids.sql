@@ -0,0 +1,27 @@
+-- +goose Up
+-- the Google Reader and Fever APIs identify items, feeds and groups by
+-- integers
+ALTER TABLE posts
+    ADD COLUMN int_id bigint GENERATED BY DEFAULT AS IDENTITY UNIQUE;
+
+ALTER TABLE feeds
+    ADD COLUMN int_id bigint GENERATED BY DEFAULT AS IDENTITY UNIQUE;
+
+ALTER TABLE folders
+    ADD COLUMN int_id bigint GENERATED BY DEFAULT AS IDENTITY UNIQUE;
+
+ALTER TABLE users
+    ADD COLUMN fever_api_key text UNIQUE;
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN fever_api_key;
+
+ALTER TABLE folders
+    DROP COLUMN int_id;
+
+ALTER TABLE feeds
+    DROP COLUMN int_id;
+
+ALTER TABLE posts
+    DROP COLUMN int_id;
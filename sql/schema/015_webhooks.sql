@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE webhooks (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at timestamp NOT NULL,
+    updated_at timestamp NOT NULL,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    url text NOT NULL,
+    secret text NOT NULL,
+    feed_id uuid REFERENCES feeds (id) ON DELETE CASCADE,
+    folder_id uuid REFERENCES folders (id) ON DELETE CASCADE,
+    keyword text,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE webhook_deliveries (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at timestamp NOT NULL,
+    updated_at timestamp NOT NULL,
+    webhook_id uuid NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    post_id uuid REFERENCES posts (id) ON DELETE CASCADE,
+    status text NOT NULL DEFAULT 'pending',
+    attempts int NOT NULL DEFAULT 0,
+    next_attempt_at timestamp NOT NULL,
+    response_code int,
+    error text,
+    UNIQUE (webhook_id, post_id)
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at)
+WHERE
+    status = 'pending';
+
+-- +goose Down
+DROP TABLE webhook_deliveries;
+
+DROP TABLE webhooks;
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE digests (
+    user_id uuid PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    created_at timestamp NOT NULL,
+    updated_at timestamp NOT NULL,
+    email text NOT NULL,
+    frequency text NOT NULL CHECK (frequency IN ('daily', 'weekly')),
+    last_sent_at timestamp
+);
+
+CREATE TABLE digest_posts (
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    post_id uuid NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    sent_at timestamp NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE digest_posts;
+
+DROP TABLE digests;
@@ -0,0 +1,52 @@
+-- +goose Up
+-- tags are created here because the tag action of rules needs somewhere
+-- to put them. Tagging posts by hand with gator tag builds on the same
+-- tables
+CREATE TABLE tags (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at timestamp NOT NULL,
+    updated_at timestamp NOT NULL,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE post_tags (
+    tag_id uuid NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    post_id uuid NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    created_at timestamp NOT NULL,
+    PRIMARY KEY (tag_id, post_id)
+);
+
+CREATE TABLE rules (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at timestamp NOT NULL,
+    updated_at timestamp NOT NULL,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    field text NOT NULL CHECK (field IN ('any', 'title', 'description', 'author', 'category')),
+    is_regex boolean NOT NULL DEFAULT FALSE,
+    pattern text NOT NULL,
+    action text NOT NULL CHECK (action IN ('hide', 'read', 'star', 'tag')),
+    tag text,
+    UNIQUE (user_id, name)
+);
+
+-- rule_id remembers which rule hid a post, removing the rule shows it
+-- again
+CREATE TABLE hidden_posts (
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    post_id uuid NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    rule_id uuid REFERENCES rules (id) ON DELETE CASCADE,
+    created_at timestamp NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE hidden_posts;
+
+DROP TABLE rules;
+
+DROP TABLE post_tags;
+
+DROP TABLE tags;